@@ -3,10 +3,17 @@ package assets
 import "embed"
 
 const (
-	FontsPath     = "fonts"
-	FontWorkSans  = "WorkSans-Medium.ttf"
-	FontNotoEmoji = "NotoEmoji-Regular.ttf"
-	FontFiraCode  = "FiraCode-Regular.ttf"
+	FontsPath        = "fonts"
+	FontWorkSans     = "WorkSans-Medium.ttf"
+	FontNotoEmoji    = "NotoEmoji-Regular.ttf"
+	FontFiraCode     = "FiraCode-Regular.ttf"
+	FontFiraCodeBold = "FiraCode-Bold.ttf"
+	// EmojiArchive holds Twemoji's 72x72 images, see emoji/LICENSE-GRAPHICS
+	EmojiArchive = "emoji/twemoji-72x72.zip"
+	// ThemesPath holds the built-in themes, one JSON file each
+	ThemesPath = "themes"
+	// OpenAPIDocument describes the JSON API
+	OpenAPIDocument = "openapi.yaml"
 )
 
 // Embedded will hold all assets in memory at compile time
@@ -8,14 +8,20 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/robfig/cron/v3"
+	"github.com/welps/go-frames-scores/internal/api"
 	"github.com/welps/go-frames-scores/internal/drawing"
 	"github.com/welps/go-frames-scores/internal/frame"
+	"github.com/welps/go-frames-scores/internal/history"
 	"github.com/welps/go-frames-scores/internal/sports"
+	"github.com/welps/go-frames-scores/internal/stream"
+	"github.com/welps/go-frames-scores/internal/webhook"
 
 	"html/template"
 	"log"
 	"net/http"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -37,13 +43,64 @@ func main() {
 	defer logger.Sync()
 
 	httpClient := resty.NewWithClient(getHTTPClient(config.HTTPClientSettings))
-	client, err := sports.NewClient(httpClient, config.SportsAPIConfig.Host, config.SportsAPIConfig.APIKey)
+	client, err := sports.NewClient(
+		httpClient,
+		config.SportsAPIConfig.Host,
+		config.SportsAPIConfig.APIKey,
+		getSportsClientOptions(config.SportsAPIConfig),
+	)
 	fatalAndExitOnError(err, "Unable to create sports client")
 
-	service := sports.NewService(client)
+	matchHistory, closeHistory, err := getHistory(config.HistoryConfig)
+	fatalAndExitOnError(err, "Unable to open match history")
+
+	events := sports.NewBroker()
+	service := sports.NewService(
+		client,
+		time.Duration(config.SportsAPIConfig.UpdateTimeoutMS)*time.Millisecond,
+		events,
+		matchHistory,
+	)
+	// Start anyway when the provider is down, sports without matches are drawn as unavailable until the next update
 	err = service.UpdateMatches(context.Background(), true)
-	fatalAndExitOnError(err, "Unable to update matches")
-	drawingService := drawing.NewService(service)
+	if err != nil {
+		zap.S().Errorw("Unable to update matches", zap.Error(err))
+	}
+	fonts, err := drawing.NewFontManager()
+	fatalAndExitOnError(err, "Unable to load fonts")
+	imageFetcher, err := drawing.NewHTTPImageFetcher(httpClient, config.TeamImagesConfig.Host)
+	fatalAndExitOnError(err, "Unable to create team image fetcher")
+	images, err := drawing.NewImageLoader(
+		imageFetcher,
+		config.TeamImagesConfig.CacheDir,
+		config.TeamImagesConfig.MemoryEntries,
+		drawing.GetTeamImageMaxSize(config.ImageHeight),
+	)
+	fatalAndExitOnError(err, "Unable to create team image loader")
+	sportThemes, err := getSportThemes(config.ThemeConfig.Sports)
+	fatalAndExitOnError(err, "Unable to read sport themes")
+	themes, err := drawing.NewThemes(config.ThemeConfig.Dir, config.ThemeConfig.Default, sportThemes)
+	fatalAndExitOnError(err, "Unable to load themes")
+	drawingService, err := drawing.NewService(
+		service,
+		fonts,
+		images,
+		themes,
+		drawing.ServiceOptions{
+			ImageHeight: config.ImageHeight,
+			AspectRatio: drawing.AspectRatio(config.ImageAspectRatio),
+			Format:      drawing.ImageFormat(config.ImageFormat),
+			Budgets: map[drawing.ImageFormat]int{
+				drawing.ImageFormatPNG:  config.ImagePNGBudgetKB << 10,
+				drawing.ImageFormatJPEG: config.ImageJPEGBudgetKB << 10,
+				drawing.ImageFormatGIF:  config.ImageGIFBudgetKB << 10,
+			},
+			FrameDelay:       time.Duration(config.ImageGIFFrameDelayMS) * time.Millisecond,
+			MaxFrames:        config.ImageGIFMaxFrames,
+			RenderCacheBytes: config.RenderCacheBytes,
+		},
+	)
+	fatalAndExitOnError(err, "Unable to create drawing service")
 
 	r := getConfiguredRouter(logger)
 	r.GET(
@@ -52,24 +109,73 @@ func main() {
 		},
 	)
 
-	controller := frame.NewController(config.PublicURL, drawingService)
+	keyRegistry, err := frame.NewHubKeyRegistry(
+		httpClient,
+		config.FarcasterConfig.HubURL,
+		config.FarcasterConfig.HubAPIKey,
+	)
+	fatalAndExitOnError(err, "Unable to create farcaster key registry")
+
+	stateCodec, err := frame.NewStateCodec(config.FrameStateSecret)
+	fatalAndExitOnError(err, "Unable to create frame state codec")
+
+	controller := frame.NewController(
+		config.PublicURL,
+		drawingService,
+		frame.NewSignatureValidator(keyRegistry),
+		frame.NewScoresNavigator(drawingService),
+		stateCodec,
+	)
 	r.GET("/", controller.GetRoot)
 	r.POST("/", controller.PostRoot)
 
-	// This route only exists because farcaster cached it before I made these routes have a timestamp to bust cache
+	// This route only exists because farcaster cached it before generated images had a version in their path
 	r.GET(
 		"/generated/root.png", func(c *gin.Context) {
 			rootAsset, _ := assets.Embedded.ReadFile("root.png")
 			c.Data(http.StatusOK, "image/png", rootAsset)
 		},
 	)
-	r.GET("/generated/:timestamp/:filename", controller.Draw)
+	r.GET("/generated/:version/:filename", controller.Draw)
+
+	apiController := api.NewController(service, matchHistory)
+	v1 := r.Group("/api/v1")
+	v1.GET("/sports", apiController.GetSports)
+	v1.GET("/sports/:sport/matches", apiController.GetMatches)
+	v1.GET("/matches/:id", apiController.GetMatch)
+	v1.GET("/matches/:id/timeline", apiController.GetTimeline)
+	v1.GET("/results", apiController.GetResults)
+	v1.GET("/teams/:team/results", apiController.GetTeamResults)
+	v1.GET("/openapi.yaml", apiController.GetOpenAPI)
+
+	streamController, err := stream.NewController(
+		events,
+		stream.Options{
+			Heartbeat:  time.Duration(config.StreamConfig.HeartbeatMS) * time.Millisecond,
+			BufferSize: config.StreamConfig.BufferSize,
+		},
+	)
+	fatalAndExitOnError(err, "Unable to create event stream controller")
+	r.GET("/events", streamController.GetEvents)
+	r.GET("/events/ws", streamController.GetEventsSocket)
+
+	dispatcher, err := getWebhookDispatcher(httpClient, config.WebhookConfig)
+	fatalAndExitOnError(err, "Unable to create webhook dispatcher")
+	dispatcher.Start(events)
 
 	crontab := cron.New()
+	// Jobs overlap when an update is slow to retry, so the count is shared between them
+	var updates atomic.Int64
 	_, err = crontab.AddFunc(
 		"@every 1m", func() {
-			err = service.UpdateMatches(context.Background(), true)
-			if err != nil {
+			update := updates.Add(1)
+			quota := client.Quota()
+			if !shouldUpdateMatches(quota, config.SportsAPIConfig, update, time.Now()) {
+				zap.S().Infow("Skipping update to save sports API quota", zap.Int("remaining", quota.Remaining))
+				return
+			}
+
+			if err := service.UpdateMatches(context.Background(), true); err != nil {
 				zap.S().Error("failed to update live scores", zap.Error(err))
 			}
 		},
@@ -83,7 +189,20 @@ func main() {
 		r,
 		fmt.Sprintf(":%d", config.Port),
 		time.Duration(config.GracefulShutdownMS)*time.Millisecond,
+		// Streams of events never finish on their own, so they're ended before waiting on requests to finish
+		events.Close,
 	)
+
+	// Updates still running save to history, so they're waited on before it and the dispatcher are closed
+	<-crontab.Stop().Done()
+
+	// Deliveries still waiting to be retried are written to the dead letter log rather than lost without a trace
+	if err := dispatcher.Close(); err != nil {
+		zap.S().Errorw("Unable to close webhook dispatcher", zap.Error(err))
+	}
+	if err := closeHistory(); err != nil {
+		zap.S().Errorw("Unable to close match history", zap.Error(err))
+	}
 }
 
 func getLogger(config config.Config) *zap.Logger {
@@ -105,8 +224,15 @@ func getLogger(config config.Config) *zap.Logger {
 	return logger
 }
 
-// listenAndServe acts as http.Server#listenAndServe with additional layer of logging and graceful shutdown
-func listenAndServe(logger *zap.Logger, handler http.Handler, address string, gracefulShutdown time.Duration) {
+// listenAndServe acts as http.Server#listenAndServe with additional layer of logging and graceful shutdown. onShutdown
+// is called as the server starts shutting down
+func listenAndServe(
+	logger *zap.Logger,
+	handler http.Handler,
+	address string,
+	gracefulShutdown time.Duration,
+	onShutdown func(),
+) {
 	// Create context that listens for the interrupt signal from the OS
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -117,6 +243,7 @@ func listenAndServe(logger *zap.Logger, handler http.Handler, address string, gr
 		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second, // Large but finite value to prevent Slowloris Attack (G112). Thanks gosec.
 	}
+	srv.RegisterOnShutdown(onShutdown)
 
 	// Initializing the server in a goroutine so that it won't block the graceful shutdown handling below
 	go func() {
@@ -167,6 +294,85 @@ func getConfiguredRouter(logger *zap.Logger) *gin.Engine {
 	return r
 }
 
+func getSportsClientOptions(settings config.SportsAPIConfig) sports.ClientOptions {
+	return sports.ClientOptions{
+		MaxPages:          settings.MaxPages,
+		MaxRetries:        settings.MaxRetries,
+		RetryBaseDelay:    time.Duration(settings.RetryBaseDelayMS) * time.Millisecond,
+		RetryMaxDelay:     time.Duration(settings.RetryMaxDelayMS) * time.Millisecond,
+		RequestsPerSecond: settings.RequestsPerSecond,
+		Burst:             settings.Burst,
+	}
+}
+
+// getSportThemes reads sport themes written as slug=theme, rejecting sports that don't exist
+func getSportThemes(settings []string) (map[string]string, error) {
+	sportThemes := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		slug, theme, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok {
+			return nil, fmt.Errorf("sport theme %q isn't written as slug=theme", setting)
+		}
+		if _, ok := sports.GetSportBySlug(slug); !ok {
+			return nil, fmt.Errorf("sport theme %q is for an unknown sport", setting)
+		}
+		sportThemes[slug] = theme
+	}
+
+	return sportThemes, nil
+}
+
+// shouldUpdateMatches slows scheduled updates down as the sports API quota runs out, rather than getting cut off
+func shouldUpdateMatches(quota sports.Quota, settings config.SportsAPIConfig, update int64, now time.Time) bool {
+	switch {
+	case quota.IsExhausted(now):
+		return false
+	case quota.IsLow(settings.QuotaLowWatermark):
+		return update%int64(max(settings.QuotaSlowdown, 1)) == 0
+	}
+
+	return true
+}
+
+// getHistory opens the database match history is kept in, there's no history without a path to keep it at. The
+// returned func closes it
+func getHistory(settings config.HistoryConfig) (sports.HistoryRepository, func() error, error) {
+	if settings.DBPath == "" {
+		return nil, func() error { return nil }, nil
+	}
+
+	repository, err := history.NewSQLiteRepository(context.Background(), settings.DBPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repository, repository.Close, nil
+}
+
+// getWebhookDispatcher loads the webhook subscribers, there are none without a subscribers file
+func getWebhookDispatcher(httpClient *resty.Client, settings config.WebhookConfig) (*webhook.Dispatcher, error) {
+	var subscribers []webhook.Subscriber
+	if settings.SubscribersFile != "" {
+		var err error
+		if subscribers, err = webhook.LoadSubscribers(settings.SubscribersFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return webhook.NewDispatcher(
+		httpClient,
+		subscribers,
+		webhook.Options{
+			MaxAttempts:    settings.MaxAttempts,
+			RetryBaseDelay: time.Duration(settings.RetryBaseDelayMS) * time.Millisecond,
+			RetryMaxDelay:  time.Duration(settings.RetryMaxDelayMS) * time.Millisecond,
+			Workers:        settings.Workers,
+			QueueSize:      settings.QueueSize,
+			DeadLetterPath: settings.DeadLetterFile,
+		},
+	)
+}
+
 // getHTTPClient returns a configured HTTP client with sane defaults
 func getHTTPClient(settings config.HTTPClientSettings) *http.Client {
 	t := http.DefaultTransport.(*http.Transport).Clone()
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/config"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+func TestShouldUpdateMatches(t *testing.T) {
+	now := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+	settings := config.SportsAPIConfig{QuotaLowWatermark: 50, QuotaSlowdown: 5}
+
+	tests := []struct {
+		name     string
+		quota    sports.Quota
+		settings config.SportsAPIConfig
+		// wantUpdates are the updates out of the first ten that run
+		wantUpdates []int64
+	}{
+		{
+			name:        "quota unknown",
+			settings:    settings,
+			wantUpdates: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			name:        "plenty left",
+			quota:       sports.Quota{Known: true, Remaining: 400},
+			settings:    settings,
+			wantUpdates: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			name:        "running low",
+			quota:       sports.Quota{Known: true, Remaining: 49},
+			settings:    settings,
+			wantUpdates: []int64{5, 10},
+		},
+		{
+			name:        "none left without a reset",
+			quota:       sports.Quota{Known: true},
+			settings:    settings,
+			wantUpdates: []int64{5, 10},
+		},
+		{
+			name:     "none left until a reset",
+			quota:    sports.Quota{Known: true, ResetAt: now.Add(time.Hour)},
+			settings: settings,
+		},
+		{
+			name:        "none left and the quota reset",
+			quota:       sports.Quota{Known: true, ResetAt: now.Add(-time.Minute)},
+			settings:    settings,
+			wantUpdates: []int64{5, 10},
+		},
+		{
+			name:        "running low without slowing down",
+			quota:       sports.Quota{Known: true, Remaining: 10},
+			settings:    config.SportsAPIConfig{QuotaLowWatermark: 50},
+			wantUpdates: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				var updates []int64
+				for update := int64(1); update <= 10; update++ {
+					if shouldUpdateMatches(test.quota, test.settings, update, now) {
+						updates = append(updates, update)
+					}
+				}
+				if !slices.Equal(updates, test.wantUpdates) {
+					t.Errorf("ran updates %v, want %v", updates, test.wantUpdates)
+				}
+			},
+		)
+	}
+}
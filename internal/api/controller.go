@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/welps/go-frames-scores/assets"
+	"github.com/welps/go-frames-scores/internal/httpcache"
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+)
+
+// Controller serves the cached matches as JSON. Responses carry an ETag of the matches' version, so clients polling
+// for changes get a 304 until there are some. Finished matches are served from history when it's kept
+type Controller struct {
+	sportsService sports.Service
+	history       sports.HistoryRepository
+}
+
+// NewController returns a controller for sportsService, history can be nil when none is kept
+func NewController(sportsService sports.Service, history sports.HistoryRepository) *Controller {
+	return &Controller{
+		sportsService: sportsService,
+		history:       history,
+	}
+}
+
+func (c *Controller) GetSports(ctx *gin.Context) {
+	response := SportList{Sports: make([]Sport, 0, len(sports.Sports()))}
+	hash := fnv.New64a()
+	for _, sport := range sports.Sports() {
+		status, ok := c.sportsService.GetStatus(ctx, sport.GameType, true)
+		response.Sports = append(response.Sports, NewSport(sport, status, ok))
+		_, _ = fmt.Fprintf(hash, "%s|%t|%s|%t\n", sport.Slug, ok, status.Version, status.Stale)
+	}
+
+	writeCached(ctx, strconv.FormatUint(hash.Sum64(), 36), time.Time{}, response)
+}
+
+// GetMatches serves a sport's live matches, the only ones kept up to date
+func (c *Controller) GetMatches(ctx *gin.Context) {
+	sport, ok := sports.GetSportBySlug(ctx.Param("sport"))
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "sport not found"})
+		return
+	}
+
+	// Only live matches are kept, so there are no others to list
+	if query := ctx.Query("live"); query != "" {
+		if live, err := strconv.ParseBool(query); err != nil || !live {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "only live matches are listed"})
+			return
+		}
+	}
+
+	// The status is read along with the matches, so the etag is always of the matches served
+	matches, status, err := c.sportsService.GetMatchesWithStatus(ctx, sport.GameType, true)
+	if errors.Is(err, sports.ErrMatchesUnavailable) {
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "matches are unavailable"})
+		return
+	}
+	if err != nil {
+		zap.S().Errorw("Unable to get matches", "sport", sport.Slug, zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	response := MatchList{
+		Sport:     sport.Slug,
+		UpdatedAt: status.UpdatedAt,
+		Stale:     status.Stale,
+		Matches:   make([]Match, 0, len(matches)),
+	}
+	for _, match := range matches {
+		response.Matches = append(response.Matches, NewMatch(match))
+	}
+
+	writeCached(ctx, getETag(status, "live"), status.UpdatedAt, response)
+}
+
+func (c *Controller) GetMatch(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id must be a number"})
+		return
+	}
+
+	match, status, err := c.sportsService.GetMatchWithStatus(ctx, id)
+	if errors.Is(err, sports.ErrMatchNotFound) {
+		// Matches drop out of the cache once they're no longer live
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+	if err != nil {
+		zap.S().Errorw("Unable to get match", "id", id, zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	response := MatchDetail{
+		UpdatedAt: status.UpdatedAt,
+		Stale:     status.Stale,
+		Match:     NewMatch(match),
+	}
+	writeCached(ctx, getETag(status, strconv.Itoa(id)), status.UpdatedAt, response)
+}
+
+// GetOpenAPI serves the document describing the API
+func (c *Controller) GetOpenAPI(ctx *gin.Context) {
+	document, err := assets.Embedded.ReadFile(assets.OpenAPIDocument)
+	if err != nil {
+		zap.S().Errorw("Unable to read OpenAPI document", zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/yaml", document)
+}
+
+// getETag is the version of a response drawn from matches with status, which is different for every resource
+func getETag(status sports.CacheStatus, resource string) string {
+	etag := fmt.Sprintf("%s-%s", status.Version, resource)
+	if status.Stale {
+		etag += "-stale"
+	}
+
+	return etag
+}
+
+// writeCached responds with body unless the client already has the version of it tagged etag
+func writeCached(ctx *gin.Context, etag string, updatedAt time.Time, body any) {
+	etag = fmt.Sprintf(`"%s"`, etag)
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "no-cache")
+	if !updatedAt.IsZero() {
+		ctx.Header("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if httpcache.MatchesETag(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, body)
+}
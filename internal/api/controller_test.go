@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+var (
+	testUpdatedAt = time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+	testStatus    = sports.CacheStatus{Version: "1", UpdatedAt: testUpdatedAt}
+)
+
+// stubSportsService has a live basketball match. Only reads that return matches along with their status are
+// implemented, so a handler reading the status apart from its matches panics
+type stubSportsService struct {
+	sports.Service
+}
+
+func (stubSportsService) GetMatchesWithStatus(_ context.Context, gameType sports.GameType, live bool) (
+	[]sports.Match,
+	sports.CacheStatus,
+	error,
+) {
+	if gameType != sports.Basketball || !live {
+		return nil, sports.CacheStatus{}, sports.ErrMatchesUnavailable
+	}
+
+	return []sports.Match{{ID: 7, GameType: sports.Basketball}}, testStatus, nil
+}
+
+func (stubSportsService) GetMatchWithStatus(_ context.Context, id int) (sports.Match, sports.CacheStatus, error) {
+	if id != 7 {
+		return sports.Match{}, sports.CacheStatus{}, sports.ErrMatchNotFound
+	}
+
+	return sports.Match{ID: 7, GameType: sports.Basketball}, testStatus, nil
+}
+
+// stubHistory has a finished basketball and tennis match, recording what it was asked for
+type stubHistory struct {
+	from  time.Time
+	to    time.Time
+	team  string
+	limit int
+}
+
+func (h *stubHistory) SaveMatches(context.Context, sports.GameType, bool, []sports.Match, time.Time) error {
+	return nil
+}
+
+func (h *stubHistory) GetResults(_ context.Context, from time.Time, to time.Time) ([]sports.Result, error) {
+	h.from, h.to = from, to
+	return []sports.Result{
+		{Match: sports.Match{ID: 7, GameType: sports.Basketball}, FinishedAt: from.Add(time.Hour)},
+		{Match: sports.Match{ID: 8, GameType: sports.Tennis}, FinishedAt: from.Add(2 * time.Hour)},
+	}, nil
+}
+
+func (h *stubHistory) GetTeamResults(_ context.Context, team string, limit int) ([]sports.Result, error) {
+	h.team, h.limit = team, limit
+	return []sports.Result{{Match: sports.Match{ID: 8, GameType: sports.Tennis}, FinishedAt: testUpdatedAt}}, nil
+}
+
+func (h *stubHistory) GetTimeline(_ context.Context, id int) (sports.Match, []sports.Snapshot, error) {
+	if id != 8 {
+		return sports.Match{}, nil, sports.ErrMatchNotFound
+	}
+
+	snapshots := []sports.Snapshot{{SeenAt: testUpdatedAt, Status: "finished"}}
+	return sports.Match{ID: 8, GameType: sports.Tennis}, snapshots, nil
+}
+
+// newTestRouter routes the API the way the server does, history can be nil for an API without it
+func newTestRouter(history sports.HistoryRepository) *gin.Engine {
+	controller := NewController(stubSportsService{}, history)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/sports/:sport/matches", controller.GetMatches)
+	r.GET("/matches/:id", controller.GetMatch)
+	r.GET("/matches/:id/timeline", controller.GetTimeline)
+	r.GET("/results", controller.GetResults)
+	r.GET("/teams/:team/results", controller.GetTeamResults)
+
+	return r
+}
+
+func serveTestRequest(r http.Handler, path string, ifNoneMatch string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request)
+
+	return w
+}
+
+func TestControllerCachedMatches(t *testing.T) {
+	r := newTestRouter(nil)
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		wantStatus  int
+		wantETag    string
+	}{
+		{name: "matches", path: "/sports/basketball/matches", wantStatus: http.StatusOK, wantETag: `"1-live"`},
+		{name: "live matches", path: "/sports/basketball/matches?live=true", wantStatus: http.StatusOK, wantETag: `"1-live"`},
+		{name: "matches that aren't live", path: "/sports/basketball/matches?live=false", wantStatus: http.StatusBadRequest},
+		{name: "live isn't a boolean", path: "/sports/basketball/matches?live=maybe", wantStatus: http.StatusBadRequest},
+		{name: "unknown sport", path: "/sports/curling/matches", wantStatus: http.StatusNotFound},
+		{name: "sport not updated yet", path: "/sports/tennis/matches", wantStatus: http.StatusServiceUnavailable},
+		{
+			name:        "matches the client has",
+			path:        "/sports/basketball/matches",
+			ifNoneMatch: `"1-live"`,
+			wantStatus:  http.StatusNotModified,
+			wantETag:    `"1-live"`,
+		},
+		{
+			name:        "matches the client has an old version of",
+			path:        "/sports/basketball/matches",
+			ifNoneMatch: `"0-live"`,
+			wantStatus:  http.StatusOK,
+			wantETag:    `"1-live"`,
+		},
+		{name: "match", path: "/matches/7", wantStatus: http.StatusOK, wantETag: `"1-7"`},
+		{
+			name:        "match the client has",
+			path:        "/matches/7",
+			ifNoneMatch: `W/"1-7"`,
+			wantStatus:  http.StatusNotModified,
+			wantETag:    `"1-7"`,
+		},
+		{name: "match that isn't live", path: "/matches/8", wantStatus: http.StatusNotFound},
+		{name: "match id isn't a number", path: "/matches/seven", wantStatus: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				w := serveTestRequest(r, test.path, test.ifNoneMatch)
+				if w.Code != test.wantStatus {
+					t.Fatalf("GET %s = %d, want %d: %s", test.path, w.Code, test.wantStatus, w.Body.String())
+				}
+				if got := w.Header().Get("ETag"); got != test.wantETag {
+					t.Errorf("ETag = %q, want %q", got, test.wantETag)
+				}
+				if test.wantStatus == http.StatusNotModified && w.Body.Len() > 0 {
+					t.Errorf("304 has a body: %s", w.Body.String())
+				}
+			},
+		)
+	}
+}
+
+func TestControllerHistory(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		noHistory  bool
+		wantStatus int
+		wantIDs    []int
+		// wantFrom, wantTeam and wantLimit are what history is asked for, when they're set
+		wantFrom  time.Time
+		wantTeam  string
+		wantLimit int
+	}{
+		{name: "today's results", path: "/results", wantStatus: http.StatusOK, wantIDs: []int{7, 8}, wantFrom: today},
+		{
+			name:       "a day's results",
+			path:       "/results?date=2024-07-01",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{7, 8},
+			wantFrom:   day,
+		},
+		{
+			name:       "a sport's results",
+			path:       "/results?date=2024-07-01&sport=tennis",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{8},
+		},
+		{name: "results of a badly written date", path: "/results?date=01/07/2024", wantStatus: http.StatusBadRequest},
+		{name: "results of an unknown sport", path: "/results?sport=curling", wantStatus: http.StatusBadRequest},
+		{name: "results without history", path: "/results", noHistory: true, wantStatus: http.StatusNotFound},
+		{
+			name:       "team results",
+			path:       "/teams/NAD/results",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{8},
+			wantTeam:   "NAD",
+			wantLimit:  defaultTeamResults,
+		},
+		{
+			name:       "limited team results",
+			path:       "/teams/NAD/results?limit=5",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{8},
+			wantTeam:   "NAD",
+			wantLimit:  5,
+		},
+		{name: "no team results", path: "/teams/NAD/results?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "too many team results", path: "/teams/NAD/results?limit=101", wantStatus: http.StatusBadRequest},
+		{name: "team results limit isn't a number", path: "/teams/NAD/results?limit=all", wantStatus: http.StatusBadRequest},
+		{name: "timeline", path: "/matches/8/timeline", wantStatus: http.StatusOK, wantIDs: []int{8}},
+		{name: "timeline of an unknown match", path: "/matches/9/timeline", wantStatus: http.StatusNotFound},
+		{name: "timeline match id isn't a number", path: "/matches/eight/timeline", wantStatus: http.StatusBadRequest},
+		{name: "timeline without history", path: "/matches/8/timeline", noHistory: true, wantStatus: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				history := &stubHistory{}
+				r := newTestRouter(history)
+				if test.noHistory {
+					r = newTestRouter(nil)
+				}
+
+				w := serveTestRequest(r, test.path, "")
+				if w.Code != test.wantStatus {
+					t.Fatalf("GET %s = %d, want %d: %s", test.path, w.Code, test.wantStatus, w.Body.String())
+				}
+				if test.wantStatus != http.StatusOK {
+					return
+				}
+
+				if ids := getResponseMatchIDs(t, w.Body.Bytes()); fmt.Sprint(ids) != fmt.Sprint(test.wantIDs) {
+					t.Errorf("GET %s served matches %v, want %v", test.path, ids, test.wantIDs)
+				}
+				wantTo := test.wantFrom.AddDate(0, 0, 1)
+				if !test.wantFrom.IsZero() && (!history.from.Equal(test.wantFrom) || !history.to.Equal(wantTo)) {
+					t.Errorf("results from %s to %s, want from %s to %s", history.from, history.to, test.wantFrom, wantTo)
+				}
+				if history.team != test.wantTeam || history.limit != test.wantLimit {
+					t.Errorf(
+						"results of team %q limited to %d, want %q limited to %d",
+						history.team,
+						history.limit,
+						test.wantTeam,
+						test.wantLimit,
+					)
+				}
+			},
+		)
+	}
+}
+
+// getResponseMatchIDs reads the ids of the matches of results or of a timeline
+func getResponseMatchIDs(t *testing.T, body []byte) []int {
+	t.Helper()
+
+	var response struct {
+		Results []Result `json:"results"`
+		Match   *Match   `json:"match"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, result := range response.Results {
+		ids = append(ids, result.Match.ID)
+	}
+	if response.Match != nil {
+		ids = append(ids, response.Match.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// defaultTeamResults and maxTeamResults bound how many results of a team are served at once
+	defaultTeamResults = 10
+	maxTeamResults     = 100
+)
+
+// GetResults serves the matches that finished on a UTC day, today unless the date query says otherwise. The sport
+// query only serves one sport's
+func (c *Controller) GetResults(ctx *gin.Context) {
+	if !c.checkHistory(ctx) {
+		return
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if query := ctx.Query("date"); query != "" {
+		var err error
+		if day, err = time.Parse(dateLayout, query); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "date must be written as YYYY-MM-DD"})
+			return
+		}
+	}
+
+	var sport *sports.Sport
+	if slug := ctx.Query("sport"); slug != "" {
+		registered, ok := sports.GetSportBySlug(slug)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unknown sport"})
+			return
+		}
+		sport = &registered
+	}
+
+	results, err := c.history.GetResults(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		zap.S().Errorw("Unable to get results", "date", day.Format(dateLayout), zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	response := ResultList{Date: day.Format(dateLayout), Results: make([]Result, 0, len(results))}
+	for _, result := range results {
+		if sport != nil && result.Match.GameType != sport.GameType {
+			continue
+		}
+		response.Results = append(response.Results, NewResult(result))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTeamResults serves a team's last results, as many as the limit query asks for up to maxTeamResults
+func (c *Controller) GetTeamResults(ctx *gin.Context) {
+	if !c.checkHistory(ctx) {
+		return
+	}
+
+	limit := defaultTeamResults
+	if query := ctx.Query("limit"); query != "" {
+		var err error
+		if limit, err = strconv.Atoi(query); err != nil || limit <= 0 || limit > maxTeamResults {
+			ctx.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "limit must be a number from 1 to " + strconv.Itoa(maxTeamResults)},
+			)
+			return
+		}
+	}
+
+	team := ctx.Param("team")
+	results, err := c.history.GetTeamResults(ctx, team, limit)
+	if err != nil {
+		zap.S().Errorw("Unable to get team results", "team", team, zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	response := ResultList{Team: team, Results: make([]Result, 0, len(results))}
+	for _, result := range results {
+		response.Results = append(response.Results, NewResult(result))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTimeline serves a match's score every time it changed, including matches that are no longer live
+func (c *Controller) GetTimeline(ctx *gin.Context) {
+	if !c.checkHistory(ctx) {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id must be a number"})
+		return
+	}
+
+	match, snapshots, err := c.history.GetTimeline(ctx, id)
+	if errors.Is(err, sports.ErrMatchNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+	if err != nil {
+		zap.S().Errorw("Unable to get match timeline", "id", id, zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	response := Timeline{Match: NewMatch(match), Snapshots: make([]Snapshot, 0, len(snapshots))}
+	for _, snapshot := range snapshots {
+		response.Snapshots = append(response.Snapshots, NewSnapshot(snapshot))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// checkHistory responds with a 404 when no history is kept, ok is false when it has
+func (c *Controller) checkHistory(ctx *gin.Context) bool {
+	if c.history == nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "match history isn't kept"})
+		return false
+	}
+
+	return true
+}
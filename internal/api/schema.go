@@ -0,0 +1,212 @@
+package api
+
+import (
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// Sport is a sport there are scores for and how fresh they are. UpdatedAt is missing until its matches have been
+// updated once, Stale is set when the last update failed and its matches are left over from an earlier one
+type Sport struct {
+	Slug      string     `json:"slug"`
+	Name      string     `json:"name"`
+	Emoji     string     `json:"emoji"`
+	Available bool       `json:"available"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Stale     bool       `json:"stale"`
+}
+
+type SportList struct {
+	Sports []Sport `json:"sports"`
+}
+
+// MatchList is a sport's matches as of their last update
+type MatchList struct {
+	Sport     string    `json:"sport"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Stale     bool      `json:"stale"`
+	Matches   []Match   `json:"matches"`
+}
+
+// MatchDetail is a match as of the last update of its sport
+type MatchDetail struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Stale     bool      `json:"stale"`
+	Match     Match     `json:"match"`
+}
+
+// ResultList is matches that finished, the latest first. Date is set for a day's results and Team for a team's
+type ResultList struct {
+	Date    string   `json:"date,omitempty"`
+	Team    string   `json:"team,omitempty"`
+	Results []Result `json:"results"`
+}
+
+// Result is a match as it was last seen before it finished
+type Result struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Match      Match     `json:"match"`
+}
+
+// Timeline is a match as it was last seen and its score every time it changed, the earliest first
+type Timeline struct {
+	Match     Match      `json:"match"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+type Snapshot struct {
+	SeenAt     time.Time `json:"seen_at"`
+	Status     string    `json:"status"`
+	StatusMore string    `json:"status_more,omitempty"`
+	Score      Score     `json:"score"`
+}
+
+// Event is a change to a live match as it's streamed to clients, Previous is missing for matches that weren't in the
+// update before
+type Event struct {
+	Type     sports.EventType `json:"type"`
+	Sport    string           `json:"sport"`
+	At       time.Time        `json:"at"`
+	Match    Match            `json:"match"`
+	Previous *Match           `json:"previous,omitempty"`
+}
+
+// Match is the public schema of a match, it only ever gains fields within a version of the API
+type Match struct {
+	ID         int        `json:"id"`
+	Sport      string     `json:"sport"`
+	League     string     `json:"league"`
+	Season     string     `json:"season"`
+	Status     string     `json:"status"`
+	StatusMore string     `json:"status_more,omitempty"`
+	StartAt    *time.Time `json:"start_at,omitempty"`
+	Home       Team       `json:"home"`
+	Away       Team       `json:"away"`
+	Score      Score      `json:"score"`
+}
+
+type Team struct {
+	Name        string `json:"name"`
+	ShortName   string `json:"short_name,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Logo        string `json:"logo,omitempty"`
+	Flag        string `json:"flag,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+}
+
+// Score has a column per period, labelled by Periods. Tennis matches also have the current game's points and who's
+// serving
+type Score struct {
+	Periods   []string `json:"periods"`
+	Home      []string `json:"home"`
+	Away      []string `json:"away"`
+	HomeTotal string   `json:"home_total"`
+	AwayTotal string   `json:"away_total"`
+	HomePoint string   `json:"home_point,omitempty"`
+	AwayPoint string   `json:"away_point,omitempty"`
+	Server    string   `json:"server,omitempty"`
+}
+
+func NewSport(sport sports.Sport, status sports.CacheStatus, available bool) Sport {
+	response := Sport{
+		Slug:      sport.Slug,
+		Name:      sport.DisplayName,
+		Emoji:     sport.Emoji,
+		Available: available,
+		Stale:     status.Stale,
+	}
+	if available {
+		response.UpdatedAt = &status.UpdatedAt
+	}
+
+	return response
+}
+
+func NewEvent(event sports.Event) Event {
+	response := Event{
+		Type:  event.Type,
+		Sport: getSportSlug(event.Match.GameType),
+		At:    event.At,
+		Match: NewMatch(event.Match),
+	}
+	if event.Previous != nil {
+		previous := NewMatch(*event.Previous)
+		response.Previous = &previous
+	}
+
+	return response
+}
+
+func NewMatch(match sports.Match) Match {
+	response := Match{
+		ID:         match.ID,
+		Sport:      getSportSlug(match.GameType),
+		League:     match.League,
+		Season:     match.Season,
+		Status:     match.Status,
+		StatusMore: match.StatusMore,
+		Home:       newTeam(match.Home),
+		Away:       newTeam(match.Away),
+		Score:      newScore(match.Score),
+	}
+	if !match.StartAt.IsZero() {
+		response.StartAt = &match.StartAt
+	}
+
+	return response
+}
+
+func NewResult(result sports.Result) Result {
+	return Result{
+		FinishedAt: result.FinishedAt,
+		Match:      NewMatch(result.Match),
+	}
+}
+
+func NewSnapshot(snapshot sports.Snapshot) Snapshot {
+	return Snapshot{
+		SeenAt:     snapshot.SeenAt,
+		Status:     snapshot.Status,
+		StatusMore: snapshot.StatusMore,
+		Score:      newScore(snapshot.Score),
+	}
+}
+
+func newTeam(team sports.Team) Team {
+	return Team{
+		Name:        team.Name,
+		ShortName:   team.ShortName,
+		Code:        team.Code,
+		Logo:        team.Logo,
+		Flag:        team.Flag,
+		CountryCode: team.CountryCode,
+	}
+}
+
+func newScore(score sports.Score) Score {
+	// Columns are copied so matches without periods have empty ones rather than null
+	response := Score{
+		Periods:   score.GetPeriodLabels(),
+		Home:      append([]string{}, score.Home...),
+		Away:      append([]string{}, score.Away...),
+		HomeTotal: score.HomeTotal,
+		AwayTotal: score.AwayTotal,
+	}
+	if score.Tennis != nil {
+		response.HomePoint, response.AwayPoint = score.Tennis.HomePoint, score.Tennis.AwayPoint
+		switch score.Tennis.Server {
+		case sports.HomeSide:
+			response.Server = "home"
+		case sports.AwaySide:
+			response.Server = "away"
+		}
+	}
+
+	return response
+}
+
+func getSportSlug(gameType sports.GameType) string {
+	sport, _ := sports.GetSport(gameType)
+	return sport.Slug
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -13,9 +15,29 @@ type Config struct {
 	Port               int                   `mapstructure:"PORT"`
 	GracefulShutdownMS int                   `mapstructure:"GRACEFUL_SHUTDOWN_MS"`
 	PublicURL          string                `mapstructure:"PUBLIC_URL"`
+	FrameStateSecret   string                `mapstructure:"FRAME_STATE_SECRET"`
+	RenderCacheBytes   int                   `mapstructure:"RENDER_CACHE_BYTES"`
+	// ImageHeight is how tall images are in pixels, their width follows from ImageAspectRatio
+	ImageHeight      int    `mapstructure:"IMAGE_HEIGHT"`
+	ImageAspectRatio string `mapstructure:"IMAGE_ASPECT_RATIO"`
+	// ImageFormat is what frames are given images as, png, jpeg or gif, and the budgets are the most each format can
+	// take up before images are degraded to fit. Zero doesn't limit them
+	ImageFormat       string `mapstructure:"IMAGE_FORMAT"`
+	ImagePNGBudgetKB  int    `mapstructure:"IMAGE_PNG_BUDGET_KB"`
+	ImageJPEGBudgetKB int    `mapstructure:"IMAGE_JPEG_BUDGET_KB"`
+	ImageGIFBudgetKB  int    `mapstructure:"IMAGE_GIF_BUDGET_KB"`
+	// GIFs show each page of matches for ImageGIFFrameDelayMS, up to ImageGIFMaxFrames pages
+	ImageGIFFrameDelayMS int `mapstructure:"IMAGE_GIF_FRAME_DELAY_MS"`
+	ImageGIFMaxFrames    int `mapstructure:"IMAGE_GIF_MAX_FRAMES"`
 
 	HTTPClientSettings HTTPClientSettings `mapstructure:",squash"`
 	SportsAPIConfig    SportsAPIConfig    `mapstructure:",squash"`
+	TeamImagesConfig   TeamImagesConfig   `mapstructure:",squash"`
+	ThemeConfig        ThemeConfig        `mapstructure:",squash"`
+	FarcasterConfig    FarcasterConfig    `mapstructure:",squash"`
+	StreamConfig       StreamConfig       `mapstructure:",squash"`
+	WebhookConfig      WebhookConfig      `mapstructure:",squash"`
+	HistoryConfig      HistoryConfig      `mapstructure:",squash"`
 }
 
 type HTTPClientSettings struct {
@@ -25,8 +47,68 @@ type HTTPClientSettings struct {
 }
 
 type SportsAPIConfig struct {
-	Host   string `mapstructure:"SPORTS_API_HOST"`
-	APIKey string `mapstructure:"SPORTS_API_KEY"`
+	Host     string `mapstructure:"SPORTS_API_HOST"`
+	APIKey   string `mapstructure:"SPORTS_API_KEY"`
+	MaxPages int    `mapstructure:"SPORTS_API_MAX_PAGES"`
+
+	MaxRetries        int     `mapstructure:"SPORTS_API_MAX_RETRIES"`
+	RetryBaseDelayMS  int     `mapstructure:"SPORTS_API_RETRY_BASE_DELAY_MS"`
+	RetryMaxDelayMS   int     `mapstructure:"SPORTS_API_RETRY_MAX_DELAY_MS"`
+	RequestsPerSecond float64 `mapstructure:"SPORTS_API_REQUESTS_PER_SECOND"`
+	Burst             int     `mapstructure:"SPORTS_API_BURST"`
+	// Scheduled updates only run every QuotaSlowdown minutes once fewer than QuotaLowWatermark requests are left
+	QuotaLowWatermark int `mapstructure:"SPORTS_API_QUOTA_LOW_WATERMARK"`
+	QuotaSlowdown     int `mapstructure:"SPORTS_API_QUOTA_SLOWDOWN"`
+	// UpdateTimeoutMS bounds how long updating each sport's matches can take
+	UpdateTimeoutMS int `mapstructure:"SPORTS_UPDATE_TIMEOUT_MS"`
+}
+
+// TeamImagesConfig is where the logos and flags drawn next to team names come from and are kept
+type TeamImagesConfig struct {
+	// Host replaces the host of every image URL the provider returns, e.g. to serve them from a local stand-in
+	Host          string `mapstructure:"TEAM_IMAGES_HOST"`
+	CacheDir      string `mapstructure:"TEAM_IMAGES_CACHE_DIR"`
+	MemoryEntries int    `mapstructure:"TEAM_IMAGES_MEMORY_ENTRIES"`
+}
+
+// ThemeConfig picks the themes images are drawn with when a request doesn't ask for one
+type ThemeConfig struct {
+	Default string `mapstructure:"THEME_DEFAULT"`
+	// Dir holds extra themes, one JSON file each
+	Dir string `mapstructure:"THEMES_DIR"`
+	// Sports are sport slugs and the theme they're drawn with, e.g. "tennis=light"
+	Sports []string `mapstructure:"THEME_SPORTS"`
+}
+
+// StreamConfig controls the streams of live score events
+type StreamConfig struct {
+	HeartbeatMS int `mapstructure:"STREAM_HEARTBEAT_MS"`
+	// BufferSize is how many events a client can fall behind by before it's disconnected
+	BufferSize int `mapstructure:"STREAM_BUFFER_SIZE"`
+}
+
+// WebhookConfig is where events of live matches are posted to and how hard deliveries are retried
+type WebhookConfig struct {
+	// SubscribersFile is a JSON array of subscribers, without one no webhooks are sent
+	SubscribersFile  string `mapstructure:"WEBHOOKS_FILE"`
+	MaxAttempts      int    `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	RetryBaseDelayMS int    `mapstructure:"WEBHOOK_RETRY_BASE_DELAY_MS"`
+	RetryMaxDelayMS  int    `mapstructure:"WEBHOOK_RETRY_MAX_DELAY_MS"`
+	Workers          int    `mapstructure:"WEBHOOK_WORKERS"`
+	QueueSize        int    `mapstructure:"WEBHOOK_QUEUE_SIZE"`
+	// DeadLetterFile is where deliveries that were given up on are appended, empty only logs them
+	DeadLetterFile string `mapstructure:"WEBHOOK_DEAD_LETTER_FILE"`
+}
+
+// HistoryConfig is where every match seen is recorded, so finished matches outlive the live feed and restarts
+type HistoryConfig struct {
+	// DBPath is the SQLite database history is kept in, empty doesn't keep any
+	DBPath string `mapstructure:"HISTORY_DB_PATH"`
+}
+
+type FarcasterConfig struct {
+	HubURL    string `mapstructure:"FARCASTER_HUB_URL"`
+	HubAPIKey string `mapstructure:"FARCASTER_HUB_API_KEY"`
 }
 
 func InitConfig() Config {
@@ -34,6 +116,16 @@ func InitConfig() Config {
 	viper.SetDefault("PORT", 8080)
 	viper.SetDefault("GRACEFUL_SHUTDOWN_MS", (10 * time.Second).Milliseconds())
 	viper.SetDefault("PUBLIC_URL", "http://localhost:8080")
+	viper.SetDefault("FRAME_STATE_SECRET", "")
+	viper.SetDefault("RENDER_CACHE_BYTES", 64<<20)
+	viper.SetDefault("IMAGE_HEIGHT", 1080)
+	viper.SetDefault("IMAGE_ASPECT_RATIO", "1.91:1")
+	viper.SetDefault("IMAGE_FORMAT", "png")
+	viper.SetDefault("IMAGE_PNG_BUDGET_KB", 256)
+	viper.SetDefault("IMAGE_JPEG_BUDGET_KB", 256)
+	viper.SetDefault("IMAGE_GIF_BUDGET_KB", 2048)
+	viper.SetDefault("IMAGE_GIF_FRAME_DELAY_MS", (4 * time.Second).Milliseconds())
+	viper.SetDefault("IMAGE_GIF_MAX_FRAMES", 10)
 
 	viper.SetDefault("MAX_IDLE_CONNS", 100)
 	viper.SetDefault("MAX_IDLE_CONNS_PER_HOST", 50)
@@ -41,6 +133,42 @@ func InitConfig() Config {
 
 	viper.SetDefault("SPORTS_API_HOST", "https://sportscore1.p.rapidapi.com")
 	viper.SetDefault("SPORTS_API_KEY", "")
+	viper.SetDefault("SPORTS_API_MAX_PAGES", 10)
+	viper.SetDefault("SPORTS_API_MAX_RETRIES", 3)
+	viper.SetDefault("SPORTS_API_RETRY_BASE_DELAY_MS", (500 * time.Millisecond).Milliseconds())
+	viper.SetDefault("SPORTS_API_RETRY_MAX_DELAY_MS", (10 * time.Second).Milliseconds())
+	viper.SetDefault("SPORTS_API_REQUESTS_PER_SECOND", 5)
+	viper.SetDefault("SPORTS_API_BURST", 5)
+	viper.SetDefault("SPORTS_API_QUOTA_LOW_WATERMARK", 500)
+	viper.SetDefault("SPORTS_API_QUOTA_SLOWDOWN", 5)
+	viper.SetDefault("SPORTS_UPDATE_TIMEOUT_MS", (30 * time.Second).Milliseconds())
+
+	viper.SetDefault("TEAM_IMAGES_HOST", "")
+	viper.SetDefault("TEAM_IMAGES_CACHE_DIR", filepath.Join(os.TempDir(), "go-frames-scores", "team-images"))
+	viper.SetDefault("TEAM_IMAGES_MEMORY_ENTRIES", 512)
+
+	viper.SetDefault("THEME_DEFAULT", "dark")
+	viper.SetDefault("THEMES_DIR", "")
+	viper.SetDefault("THEME_SPORTS", []string{})
+
+	viper.SetDefault("FARCASTER_HUB_URL", "https://nemes.farcaster.xyz:2281")
+	viper.SetDefault("FARCASTER_HUB_API_KEY", "")
+
+	viper.SetDefault("STREAM_HEARTBEAT_MS", (15 * time.Second).Milliseconds())
+	viper.SetDefault("STREAM_BUFFER_SIZE", 64)
+
+	viper.SetDefault("WEBHOOKS_FILE", "")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 6)
+	viper.SetDefault("WEBHOOK_RETRY_BASE_DELAY_MS", (time.Second).Milliseconds())
+	viper.SetDefault("WEBHOOK_RETRY_MAX_DELAY_MS", (time.Minute).Milliseconds())
+	viper.SetDefault("WEBHOOK_WORKERS", 4)
+	viper.SetDefault("WEBHOOK_QUEUE_SIZE", 1024)
+	viper.SetDefault(
+		"WEBHOOK_DEAD_LETTER_FILE",
+		filepath.Join(os.TempDir(), "go-frames-scores", "webhook-dead-letters.jsonl"),
+	)
+
+	viper.SetDefault("HISTORY_DB_PATH", filepath.Join(os.TempDir(), "go-frames-scores", "history.db"))
 
 	viper.AutomaticEnv()
 
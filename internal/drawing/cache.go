@@ -0,0 +1,159 @@
+package drawing
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// renderKey identifies a rendered image. Version changes with the data drawn on it, so stale renders are never hit
+type renderKey struct {
+	screen  string
+	page    int
+	matchID int
+	version string
+	// theme is the hash of the theme the image is drawn with
+	theme string
+	// canvas is the size of the image, written as widthxheight
+	canvas string
+	// format is what the image is encoded as and the budget it's encoded within
+	format string
+}
+
+// renderRevision is part of every image's address. Bump it when the way images are drawn changes, otherwise clients
+// keep showing images they cached before the change
+const renderRevision = 12
+
+// hash addresses the image a key renders, it's short enough to go in a path
+func (k renderKey) hash() string {
+	sum := sha256.Sum256(
+		[]byte(
+			fmt.Sprintf(
+				"%d|%s|%d|%d|%s|%s|%s|%s",
+				renderRevision,
+				k.screen,
+				k.page,
+				k.matchID,
+				k.version,
+				k.theme,
+				k.canvas,
+				k.format,
+			),
+		),
+	)
+	return hex.EncodeToString(sum[:8])
+}
+
+type renderCacheEntry struct {
+	key  renderKey
+	data []byte
+}
+
+// renderCall is a render in progress that requests for the same image wait on instead of rendering it again
+type renderCall struct {
+	done chan struct{}
+	data []byte
+	// complete is false for images drawn with placeholders for images that took too long to load, they aren't kept
+	complete bool
+	err      error
+}
+
+// renderCache memoizes encoded images, evicting the least recently used once they take up more than maxBytes
+type renderCache struct {
+	maxBytes int
+	bytes    int
+	entries  map[renderKey]*list.Element
+	order    *list.List
+	inFlight map[renderKey]*renderCall
+	mutex    *sync.Mutex
+}
+
+func newRenderCache(maxBytes int) *renderCache {
+	return &renderCache{
+		maxBytes: maxBytes,
+		entries:  make(map[renderKey]*list.Element),
+		order:    list.New(),
+		inFlight: make(map[renderKey]*renderCall),
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// renderFunc draws an image, complete is false when it's missing images that may load next time
+type renderFunc func(ctx context.Context) (buf bytes.Buffer, complete bool, err error)
+
+// getOrRender returns the cached image for key, rendering it once no matter how many requests ask for it at the same
+// time. Requests stop waiting when ctx is done, but the render carries on for the others waiting on it. Only complete
+// images are cached. The returned buffer shares memory with the cache and must not be written to
+func (c *renderCache) getOrRender(ctx context.Context, key renderKey, render renderFunc) (bytes.Buffer, bool, error) {
+	c.mutex.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		data := element.Value.(*renderCacheEntry).data
+		c.mutex.Unlock()
+
+		return *bytes.NewBuffer(data), true, nil
+	}
+
+	call, ok := c.inFlight[key]
+	if !ok {
+		call = &renderCall{done: make(chan struct{})}
+		c.inFlight[key] = call
+		go c.render(key, call, render)
+	}
+	c.mutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return bytes.Buffer{}, false, ctx.Err()
+	case <-call.done:
+		return *bytes.NewBuffer(call.data), call.complete, call.err
+	}
+}
+
+// render runs a call shared between requests, so it isn't tied to any of their contexts. Panics fail the call rather
+// than leaving the requests waiting on it hanging
+func (c *renderCache) render(key renderKey, call *renderCall, render renderFunc) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.S().Errorw("Render panicked", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+			call.data, call.complete, call.err = nil, false, fmt.Errorf("render panicked: %v", r)
+		}
+
+		c.mutex.Lock()
+		delete(c.inFlight, key)
+		if call.err == nil && call.complete {
+			c.add(key, call.data)
+		}
+		c.mutex.Unlock()
+		close(call.done)
+	}()
+
+	// Requests' contexts end with them, and gin reuses its own once the request is done
+	buf, complete, err := render(context.Background())
+	call.data, call.complete, call.err = buf.Bytes(), complete, err
+}
+
+// add stores an image and evicts the least recently used ones to make room for it, callers must hold the mutex
+func (c *renderCache) add(key renderKey, data []byte) {
+	if len(data) > c.maxBytes {
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&renderCacheEntry{key: key, data: data})
+	c.bytes += len(data)
+
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*renderCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= len(entry.data)
+	}
+}
@@ -0,0 +1,132 @@
+package drawing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRenderCacheSharesRenders(t *testing.T) {
+	cache := newRenderCache(1 << 20)
+	key := renderKey{screen: rootScreen}
+
+	var renders atomic.Int32
+	release := make(chan struct{})
+	render := func(context.Context) (bytes.Buffer, bool, error) {
+		renders.Add(1)
+		<-release
+		return *bytes.NewBufferString("image"), true, nil
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			buf, _, err := cache.getOrRender(context.Background(), key, render)
+			if err != nil || buf.String() != "image" {
+				t.Errorf("getOrRender() = %q, %v", buf.String(), err)
+			}
+		}()
+	}
+	// Give every request a chance to find the render in flight before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if _, _, err := cache.getOrRender(context.Background(), key, render); err != nil {
+		t.Fatal(err)
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("rendered %d times, want 1", got)
+	}
+}
+
+func TestRenderCachePanic(t *testing.T) {
+	cache := newRenderCache(1 << 20)
+	key := renderKey{screen: rootScreen}
+
+	_, _, err := cache.getOrRender(
+		context.Background(), key, func(context.Context) (bytes.Buffer, bool, error) {
+			panic("out of fonts")
+		},
+	)
+	if err == nil {
+		t.Fatal("getOrRender() of a panicking render didn't fail")
+	}
+
+	// The failed render is neither cached nor left in flight, so the next request renders again
+	buf, _, err := cache.getOrRender(
+		context.Background(), key, func(context.Context) (bytes.Buffer, bool, error) {
+			return *bytes.NewBufferString("image"), true, nil
+		},
+	)
+	if err != nil || buf.String() != "image" {
+		t.Errorf("getOrRender() after a panic = %q, %v", buf.String(), err)
+	}
+}
+
+func TestRenderCacheCancelledRequest(t *testing.T) {
+	cache := newRenderCache(1 << 20)
+	key := renderKey{screen: rootScreen}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rendered := make(chan error, 1)
+	render := func(renderCtx context.Context) (bytes.Buffer, bool, error) {
+		cancel()
+		// The render outlives the request that started it
+		select {
+		case <-renderCtx.Done():
+			rendered <- renderCtx.Err()
+		case <-time.After(50 * time.Millisecond):
+			rendered <- nil
+		}
+		return *bytes.NewBufferString("image"), true, nil
+	}
+
+	if _, _, err := cache.getOrRender(ctx, key, render); !errors.Is(err, context.Canceled) {
+		t.Fatalf("getOrRender() error = %v, want %v", err, context.Canceled)
+	}
+	if err := <-rendered; err != nil {
+		t.Fatalf("render was cancelled with the request: %v", err)
+	}
+
+	buf, _, err := cache.getOrRender(
+		context.Background(), key, func(context.Context) (bytes.Buffer, bool, error) {
+			return bytes.Buffer{}, false, errors.New("rendered again")
+		},
+	)
+	if err != nil || buf.String() != "image" {
+		t.Errorf("getOrRender() after the request was cancelled = %q, %v", buf.String(), err)
+	}
+}
+
+func TestRenderCacheIncompleteRender(t *testing.T) {
+	cache := newRenderCache(1 << 20)
+	key := renderKey{screen: rootScreen}
+
+	renders := 0
+	render := func(context.Context) (bytes.Buffer, bool, error) {
+		renders++
+		// The first render ran out of time loading images
+		return *bytes.NewBufferString("image"), renders > 1, nil
+	}
+
+	for i, wantComplete := range []bool{false, true, true} {
+		buf, complete, err := cache.getOrRender(context.Background(), key, render)
+		if err != nil || buf.String() != "image" {
+			t.Fatalf("getOrRender() = %q, %v", buf.String(), err)
+		}
+		if complete != wantComplete {
+			t.Errorf("request %d: complete = %t, want %t", i, complete, wantComplete)
+		}
+	}
+	if renders != 2 {
+		t.Errorf("rendered %d times, want 2", renders)
+	}
+}
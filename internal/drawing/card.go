@@ -0,0 +1,76 @@
+package drawing
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// drawCard draws the box a match is drawn in, with the theme's corners and border
+func drawCard(sc *scene, theme Theme, x, y, width, height float64) {
+	sc.add(
+		rectShape{
+			x:           x,
+			y:           y,
+			width:       width,
+			height:      height,
+			radius:      theme.Card.CornerRadius,
+			fill:        theme.Colors.Card,
+			stroke:      theme.Colors.CardBorder,
+			strokeWidth: theme.Card.BorderWidth,
+		},
+	)
+}
+
+// drawLeaderHighlight tints the row of the team that's ahead, inside the border of the card at x, y. rowY is where
+// the home team's row starts, the away team's follows it
+func drawLeaderHighlight(
+	sc *scene,
+	theme Theme,
+	score sports.Score,
+	x, y, width, height, rowY, rowHeight float64,
+) {
+	leader := getLeader(score)
+	if leader == sports.NoSide {
+		return
+	}
+	if leader == sports.AwaySide {
+		rowY += rowHeight
+	}
+
+	// Keep the tint within the card's rounded corners and off its border
+	inset := theme.Card.BorderWidth / 2
+	clip := clipShape{
+		x:      x + inset,
+		y:      y + inset,
+		width:  width - 2*inset,
+		height: height - 2*inset,
+		radius: theme.Card.CornerRadius,
+	}
+	sc.withClip(
+		clip, func() {
+			sc.add(rectShape{x: x, y: rowY, width: width, height: rowHeight, fill: theme.Colors.Accent})
+		},
+	)
+}
+
+// getLeader is whichever side has the higher total, which is sets for tennis. It's NoSide when they're level or the
+// sport has no totals
+func getLeader(score sports.Score) sports.Side {
+	home, homeErr := strconv.Atoi(score.HomeTotal)
+	away, awayErr := strconv.Atoi(score.AwayTotal)
+	switch {
+	case homeErr != nil || awayErr != nil || home == away:
+		return sports.NoSide
+	case home > away:
+		return sports.HomeSide
+	}
+
+	return sports.AwaySide
+}
+
+// withAlpha scales c's opacity by alpha
+func withAlpha(c Color, alpha float64) color.NRGBA {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(float64(c.A) * alpha)}
+}
@@ -0,0 +1,204 @@
+package drawing
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/welps/go-frames-scores/assets"
+)
+
+const (
+	variationSelectorText  = '\uFE0E'
+	variationSelectorEmoji = '\uFE0F'
+	combiningKeycap        = '\u20E3'
+)
+
+// emojiPresentationRanges are the characters below the emoji planes that are drawn as emoji even without U+FE0F
+var emojiPresentationRanges = [][2]rune{
+	{0x231A, 0x231B}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE},
+	{0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE}, {0x26D4, 0x26D4},
+	{0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5}, {0x26FA, 0x26FA}, {0x26FD, 0x26FD},
+	{0x2705, 0x2705}, {0x270A, 0x270B}, {0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E},
+	{0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55},
+}
+
+// emojiSet holds the colour emoji images, named like Twemoji by their code points, e.g. "1f1fa-1f1f8" for 🇺🇸
+type emojiSet struct {
+	files map[string]*zip.File
+	// maxLength is the most code points any emoji is made of
+	maxLength int
+	images    map[string]image.Image
+	mutex     *sync.RWMutex
+}
+
+// newEmojiSet indexes the embedded emoji archive, images are only decoded once they're drawn
+func newEmojiSet() (*emojiSet, error) {
+	archive, err := assets.Embedded.ReadFile(assets.EmojiArchive)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read embedded emoji: %w", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open embedded emoji: %w", err)
+	}
+
+	set := &emojiSet{
+		files:  make(map[string]*zip.File, len(reader.File)),
+		images: make(map[string]image.Image),
+		mutex:  &sync.RWMutex{},
+	}
+	for _, file := range reader.File {
+		name := strings.TrimSuffix(path.Base(file.Name), ".png")
+		set.files[name] = file
+		set.maxLength = max(set.maxLength, strings.Count(name, "-")+1)
+	}
+
+	return set, nil
+}
+
+// getImage decodes an emoji, images are shared between renders so they must not be drawn on
+func (e *emojiSet) getImage(name string) (image.Image, error) {
+	e.mutex.RLock()
+	img, ok := e.images[name]
+	e.mutex.RUnlock()
+	if ok {
+		return img, nil
+	}
+
+	file, ok := e.files[name]
+	if !ok {
+		return nil, fmt.Errorf("no emoji %s", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to open emoji %s: %w", name, err)
+	}
+	defer reader.Close()
+
+	img, err = png.Decode(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode emoji %s: %w", name, err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.images[name] = img
+
+	return img, nil
+}
+
+// lookup finds the emoji for a sequence of code points. Twemoji leaves U+FE0F out of most names, so it's tried
+// without it too
+func (e *emojiSet) lookup(sequence []rune) (string, bool) {
+	name := getEmojiName(sequence, true)
+	if _, ok := e.files[name]; ok {
+		return name, true
+	}
+
+	name = getEmojiName(sequence, false)
+	_, ok := e.files[name]
+
+	return name, ok
+}
+
+func getEmojiName(sequence []rune, withVariationSelector bool) string {
+	codePoints := make([]string, 0, len(sequence))
+	for _, r := range sequence {
+		if r == variationSelectorEmoji && !withVariationSelector {
+			continue
+		}
+		codePoints = append(codePoints, strconv.FormatInt(int64(r), 16))
+	}
+
+	return strings.Join(codePoints, "-")
+}
+
+// textRun is part of a string drawn one way, either as text or as a single emoji
+type textRun struct {
+	text  string
+	emoji string
+}
+
+// split breaks s into runs of text and emoji, matching the longest emoji sequence it can so ZWJ sequences, flags,
+// keycaps and skin tones are drawn as one image
+func (e *emojiSet) split(s string) []textRun {
+	runes := []rune(s)
+	var runs []textRun
+	var text []rune
+
+	for i := 0; i < len(runes); {
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		if next == variationSelectorText {
+			// Asked to be drawn as text, so the selector itself isn't drawn
+			text = append(text, runes[i])
+			i += 2
+			continue
+		}
+
+		length := 0
+		name := ""
+		if isEmojiStart(runes[i], next) {
+			// Sequences can carry more variation selectors than the names they're looked up by
+			for n := min(2*e.maxLength, len(runes)-i); n > 0; n-- {
+				if found, ok := e.lookup(runes[i : i+n]); ok {
+					length, name = n, found
+					break
+				}
+			}
+		}
+
+		if length == 0 {
+			if runes[i] != variationSelectorEmoji {
+				text = append(text, runes[i])
+			}
+			i++
+			continue
+		}
+
+		if len(text) > 0 {
+			runs = append(runs, textRun{text: string(text)})
+			text = nil
+		}
+		runs = append(runs, textRun{emoji: name})
+		i += length
+	}
+
+	if len(text) > 0 {
+		runs = append(runs, textRun{text: string(text)})
+	}
+
+	return runs
+}
+
+// isEmojiStart is whether r begins an emoji, characters that are text by default need U+FE0F or a keycap after them
+func isEmojiStart(r rune, next rune) bool {
+	if next == variationSelectorEmoji || next == combiningKeycap {
+		return true
+	}
+	if r >= 0x1F000 {
+		return true
+	}
+
+	for _, presentationRange := range emojiPresentationRanges {
+		if r >= presentationRange[0] && r <= presentationRange[1] {
+			return true
+		}
+	}
+
+	return false
+}
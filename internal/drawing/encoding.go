@@ -0,0 +1,311 @@
+package drawing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	xdraw "golang.org/x/image/draw"
+)
+
+// ImageFormat is how images are encoded
+type ImageFormat string
+
+const (
+	ImageFormatPNG  ImageFormat = "png"
+	ImageFormatJPEG ImageFormat = "jpeg"
+	// ImageFormatGIF animates through every page of a sport's matches
+	ImageFormatGIF ImageFormat = "gif"
+	// ImageFormatSVG is drawn as vectors, so it's sharp at any size and never needs a budget
+	ImageFormatSVG ImageFormat = "svg"
+)
+
+// ParseImageFormat returns the format named by s, which can be a file extension with or without its dot
+func ParseImageFormat(s string) (ImageFormat, bool) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "png":
+		return ImageFormatPNG, true
+	case "jpg", "jpeg":
+		return ImageFormatJPEG, true
+	case "gif":
+		return ImageFormatGIF, true
+	case "svg":
+		return ImageFormatSVG, true
+	}
+
+	return "", false
+}
+
+// ImageContentTypes are the content types images can be encoded as, in order of preference
+func ImageContentTypes() []string {
+	return []string{
+		ImageFormatPNG.ContentType(),
+		ImageFormatJPEG.ContentType(),
+		ImageFormatGIF.ContentType(),
+		ImageFormatSVG.ContentType(),
+	}
+}
+
+// ParseImageContentType returns the format of a content type from ImageContentTypes
+func ParseImageContentType(contentType string) (ImageFormat, bool) {
+	switch contentType {
+	case ImageFormatPNG.ContentType():
+		return ImageFormatPNG, true
+	case ImageFormatJPEG.ContentType():
+		return ImageFormatJPEG, true
+	case ImageFormatGIF.ContentType():
+		return ImageFormatGIF, true
+	case ImageFormatSVG.ContentType():
+		return ImageFormatSVG, true
+	}
+
+	return "", false
+}
+
+func (f ImageFormat) ContentType() string {
+	switch f {
+	case ImageFormatJPEG:
+		return "image/jpeg"
+	case ImageFormatGIF:
+		return "image/gif"
+	case ImageFormatSVG:
+		return "image/svg+xml"
+	}
+
+	return "image/png"
+}
+
+func (f ImageFormat) extension() string {
+	switch f {
+	case ImageFormatJPEG:
+		return ".jpg"
+	case ImageFormatGIF:
+		return ".gif"
+	case ImageFormatSVG:
+		return ".svg"
+	}
+
+	return ".png"
+}
+
+// jpegQualities are tried in turn until a JPEG fits its budget
+var jpegQualities = []int{90, 75, 60, 45}
+
+// budgetScales are the sizes images are shrunk to, as a fraction of their own, once no quality fits their budget
+var budgetScales = []float64{1, 0.75, 0.5}
+
+// maxPaletteSize is as many colours as a paletted PNG can have
+const maxPaletteSize = 256
+
+// imageEncoding encodes an image one way, from best looking to smallest
+type imageEncoding func(buf *bytes.Buffer, img image.Image) error
+
+// encodeImage encodes img in format, degrading its quality and then its resolution until it's at most budget bytes.
+// A budget of zero doesn't limit the size, and images that don't fit even at their worst are encoded that way anyway
+func encodeImage(img image.Image, format ImageFormat, budget int) (bytes.Buffer, error) {
+	var buf bytes.Buffer
+	for _, scale := range budgetScales {
+		scaled := scaleImage(img, scale)
+		for _, encode := range getImageEncodings(format) {
+			buf.Reset()
+			if err := encode(&buf, scaled); err != nil {
+				return bytes.Buffer{}, err
+			}
+			if budget <= 0 || buf.Len() <= budget {
+				return buf, nil
+			}
+		}
+	}
+
+	zap.S().Warnw("Image is over its size budget", "format", format, "bytes", buf.Len(), "budget", budget)
+	return buf, nil
+}
+
+// encodeAnimation encodes frames as a GIF that shows each of them for delay and loops forever. Like encodeImage, the
+// frames are shrunk until the whole animation is at most budget bytes. Animations that don't fit even at their smallest
+// drop their last frames until they do, down to only the first one
+func encodeAnimation(frames []image.Image, delay time.Duration, budget int) (bytes.Buffer, error) {
+	var buf bytes.Buffer
+	for count := len(frames); count > 0; count-- {
+		// Frames are only dropped once they're as small as they get
+		scales := budgetScales
+		if count < len(frames) {
+			scales = budgetScales[len(budgetScales)-1:]
+		}
+		for _, scale := range scales {
+			var err error
+			buf, err = encodeGIF(frames[:count], delay, scale)
+			if err != nil {
+				return bytes.Buffer{}, err
+			}
+			if budget <= 0 || buf.Len() <= budget {
+				if count < len(frames) {
+					zap.S().Warnw(
+						"Dropped frames to fit animation in its size budget",
+						"frames", len(frames),
+						"kept", count,
+						"budget", budget,
+					)
+				}
+				return buf, nil
+			}
+		}
+	}
+
+	zap.S().Warnw("First frame of animation is over its size budget", "bytes", buf.Len(), "budget", budget)
+	return buf, nil
+}
+
+// encodeGIF encodes frames shrunk to scale times their size as an animation
+func encodeGIF(frames []image.Image, delay time.Duration, scale float64) (bytes.Buffer, error) {
+	scaled := make([]*image.RGBA, 0, len(frames))
+	for _, frame := range frames {
+		scaled = append(scaled, toRGBA(scaleImage(frame, scale)))
+	}
+
+	// Every frame shares a palette so the GIF only needs the one colour table
+	palette := getPalette(scaled...)
+	animation := &gif.GIF{}
+	for _, frame := range scaled {
+		animation.Image = append(animation.Image, toPaletted(frame, palette))
+		animation.Delay = append(animation.Delay, int(delay/(10*time.Millisecond)))
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	return buf, nil
+}
+
+func getImageEncodings(format ImageFormat) []imageEncoding {
+	if format == ImageFormatJPEG {
+		encodings := make([]imageEncoding, 0, len(jpegQualities))
+		for _, quality := range jpegQualities {
+			options := &jpeg.Options{Quality: quality}
+			encodings = append(
+				encodings, func(buf *bytes.Buffer, img image.Image) error {
+					return jpeg.Encode(buf, img, options)
+				},
+			)
+		}
+		return encodings
+	}
+
+	// Scoreboards are mostly flat colour, so they rarely lose much to a palette. Higher compression levels take
+	// several times as long for a tenth off the size, which a palette beats anyway
+	return []imageEncoding{
+		func(buf *bytes.Buffer, img image.Image) error {
+			return png.Encode(buf, img)
+		},
+		func(buf *bytes.Buffer, img image.Image) error {
+			rgba := toRGBA(img)
+			return png.Encode(buf, toPaletted(rgba, getPalette(rgba)))
+		},
+	}
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// getPalette is the most common colours across images. Scoreboards are a handful of flat colours plus the shades
+// anti-aliasing blends between them, so the flat colours always make it in and the rarest blends are what's lost
+func getPalette(images ...*image.RGBA) color.Palette {
+	counts := make(map[color.RGBA]int)
+	for _, img := range images {
+		forEachPixel(
+			img, func(c color.RGBA) {
+				counts[c]++
+			},
+		)
+	}
+
+	colors := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+	sort.Slice(
+		colors, func(i, j int) bool {
+			if counts[colors[i]] != counts[colors[j]] {
+				return counts[colors[i]] > counts[colors[j]]
+			}
+			// Break ties the same way every time, so the same image is always encoded the same way
+			a, b := colors[i], colors[j]
+			return a.R < b.R || a.R == b.R && (a.G < b.G || a.G == b.G && (a.B < b.B || a.B == b.B && a.A < b.A))
+		},
+	)
+
+	palette := make(color.Palette, 0, maxPaletteSize)
+	for _, c := range colors[:min(len(colors), maxPaletteSize)] {
+		palette = append(palette, c)
+	}
+
+	return palette
+}
+
+// toPaletted maps each pixel of img to the closest colour in palette without dithering, which would speckle the flat
+// backgrounds. There are few distinct colours, so each one's closest is only searched for once
+func toPaletted(img *image.RGBA, palette color.Palette) *image.Paletted {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette)
+	indexes := make(map[color.RGBA]uint8)
+	pixel := 0
+	forEachPixel(
+		img, func(c color.RGBA) {
+			index, ok := indexes[c]
+			if !ok {
+				index = uint8(palette.Index(c))
+				indexes[c] = index
+			}
+			paletted.Pix[pixel] = index
+			pixel++
+		},
+	)
+
+	return paletted
+}
+
+// forEachPixel calls fn with the colour of every pixel of img, row by row
+func forEachPixel(img *image.RGBA, fn func(c color.RGBA)) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(bounds.Min.X, y):img.PixOffset(bounds.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			fn(color.RGBA{R: row[i], G: row[i+1], B: row[i+2], A: row[i+3]})
+		}
+	}
+}
+
+// scaleImage shrinks img to scale times its size
+func scaleImage(img image.Image, scale float64) image.Image {
+	if scale == 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width := int(math.Round(float64(bounds.Dx()) * scale))
+	height := int(math.Round(float64(bounds.Dy()) * scale))
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, xdraw.Src, nil)
+
+	return scaled
+}
@@ -0,0 +1,95 @@
+package drawing
+
+import (
+	"bytes"
+	"fmt"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+type encodingBenchmark struct {
+	name   string
+	encode imageEncoding
+}
+
+// BenchmarkEncodeImage measures each encoding encodeImage can pick for a scoreboard, reporting how large it is
+func BenchmarkEncodeImage(b *testing.B) {
+	img := drawTestImage(b)
+
+	encodings := getImageEncodings(ImageFormatPNG)
+	benchmarks := []encodingBenchmark{
+		{name: "png", encode: encodings[0]},
+		{name: "paletted png", encode: encodings[1]},
+	}
+	for i, encode := range getImageEncodings(ImageFormatJPEG) {
+		name := fmt.Sprintf("jpeg quality %d", jpegQualities[i])
+		benchmarks = append(benchmarks, encodingBenchmark{name: name, encode: encode})
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(
+			benchmark.name, func(b *testing.B) {
+				var buf bytes.Buffer
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					buf.Reset()
+					if err := benchmark.encode(&buf, img); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(buf.Len()), "bytes")
+			},
+		)
+	}
+}
+
+func TestEncodeAnimationBudget(t *testing.T) {
+	frames := drawTestPages(t, 3)
+	width, shrunkWidth := frames[0].Bounds().Dx(), scaleImage(frames[0], 0.5).Bounds().Dx()
+	getSize := func(count int, scale float64) int {
+		buf, err := encodeGIF(frames[:count], time.Second, scale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Len()
+	}
+
+	tests := []struct {
+		name       string
+		budget     int
+		wantFrames int
+		wantWidth  int
+	}{
+		{name: "no budget", wantFrames: 3, wantWidth: width},
+		{name: "fits", budget: getSize(3, 1), wantFrames: 3, wantWidth: width},
+		{name: "shrunk to fit", budget: getSize(3, 0.5), wantFrames: 3, wantWidth: shrunkWidth},
+		{name: "last frame dropped", budget: getSize(2, 0.5), wantFrames: 2, wantWidth: shrunkWidth},
+		{name: "first frame when nothing fits", budget: 1, wantFrames: 1, wantWidth: shrunkWidth},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				buf, err := encodeAnimation(frames, time.Second, test.budget)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if test.budget > 1 && buf.Len() > test.budget {
+					t.Errorf("animation is %d bytes, over its budget of %d", buf.Len(), test.budget)
+				}
+
+				animation, err := gif.DecodeAll(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(animation.Image) != test.wantFrames {
+					t.Errorf("animation has %d frames, want %d", len(animation.Image), test.wantFrames)
+				}
+				if animation.Config.Width != test.wantWidth {
+					t.Errorf("animation is %d pixels wide, want %d", animation.Config.Width, test.wantWidth)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,144 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// newTestService draws with the embedded fonts and themes. It has no sports service, so scenes are drawn from matches
+// handed to it
+func newTestService(tb testing.TB) *service {
+	tb.Helper()
+
+	themes, err := NewThemes("", "dark", nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	images, err := NewImageLoader(stubImageFetcher{}, "", 10, GetTeamImageMaxSize(1080))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return &service{
+		fonts:  newTestFontManager(tb),
+		images: images,
+		themes: themes,
+		options: ServiceOptions{
+			ImageHeight: 1080,
+			AspectRatio: AspectRatioWide,
+			Format:      ImageFormatPNG,
+			FrameDelay:  3 * time.Second,
+			MaxFrames:   5,
+		},
+		renderCache: newRenderCache(0),
+	}
+}
+
+// getTestMatches are count basketball matches between teams that only have flags, so nothing is fetched to draw them
+func getTestMatches(count int) []sports.Match {
+	countries := []struct {
+		name string
+		code string
+	}{
+		{name: "United States", code: "US"},
+		{name: "Spain", code: "ES"},
+		{name: "France", code: "FR"},
+		{name: "Serbia", code: "RS"},
+		{name: "Australia", code: "AU"},
+		{name: "Germany", code: "DE"},
+	}
+
+	matches := make([]sports.Match, 0, count)
+	for i := 0; i < count; i++ {
+		home, away := countries[i%len(countries)], countries[(i+1)%len(countries)]
+		quarters := 1 + i%4
+		score := sports.Score{}
+		homeTotal, awayTotal := 0, 0
+		for quarter := 0; quarter < quarters; quarter++ {
+			homePoints, awayPoints := 18+(i+quarter)%9, 20+(i*quarter)%7
+			homeTotal, awayTotal = homeTotal+homePoints, awayTotal+awayPoints
+			score.Home = append(score.Home, fmt.Sprint(homePoints))
+			score.Away = append(score.Away, fmt.Sprint(awayPoints))
+		}
+		score.HomeTotal, score.AwayTotal = fmt.Sprint(homeTotal), fmt.Sprint(awayTotal)
+
+		matches = append(
+			matches, sports.Match{
+				ID:       1000 + i,
+				GameType: sports.Basketball,
+				Home:     sports.Team{Name: home.name, Code: home.code, CountryCode: home.code},
+				Away:     sports.Team{Name: away.name, Code: away.code, CountryCode: away.code},
+				Score:    score,
+				League:   "Olympic Games",
+				Status:   sports.MatchStatusInProgress,
+			},
+		)
+	}
+
+	return matches
+}
+
+// drawTestScoreboard draws the first page of a sport with a full page of matches
+func drawTestScoreboard(tb testing.TB, s *service) *scene {
+	tb.Helper()
+
+	sport, _ := sports.GetSport(sports.Basketball)
+	layout := s.getGrid(AspectRatioWide)
+	sc, err := s.drawSport(
+		context.Background(),
+		s.themes.get("", sport.Slug),
+		layout,
+		sport,
+		getTestMatches(layout.perPage()),
+		0,
+		matchesAvailable,
+	)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return sc
+}
+
+// drawTestImage rasterizes a scoreboard the way it's served
+func drawTestImage(tb testing.TB) image.Image {
+	tb.Helper()
+
+	s := newTestService(tb)
+	return s.fonts.drawScene(drawTestScoreboard(tb, s))
+}
+
+// drawTestPages rasterizes the first pages of a sport with enough matches to fill them, smaller than they're served to
+// keep encoding them quick
+func drawTestPages(tb testing.TB, pages int) []image.Image {
+	tb.Helper()
+
+	s := newTestService(tb)
+	s.options.ImageHeight = 360
+	sport, _ := sports.GetSport(sports.Basketball)
+	layout := s.getGrid(AspectRatioWide)
+	scenes, err := s.DrawSportPages(
+		context.Background(),
+		s.themes.get("", sport.Slug),
+		layout,
+		sport,
+		getTestMatches(pages*layout.perPage()),
+		matchesAvailable,
+		pages,
+	)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	frames := make([]image.Image, 0, len(scenes))
+	for _, sc := range scenes {
+		frames = append(frames, s.fonts.drawScene(sc))
+	}
+
+	return frames
+}
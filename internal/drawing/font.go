@@ -2,33 +2,215 @@ package drawing
 
 import (
 	"fmt"
+	"image"
+	"sync"
+
 	"github.com/goki/freetype/truetype"
 	"github.com/welps/go-frames-scores/assets"
-	"go.uber.org/zap"
 	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
-// GetFont returns a new font every time because it's not concurrent safe
-func GetFont(fontType string, size float64) truetype.IndexableFace {
-	embeddedFont, err := assets.Embedded.ReadFile(fmt.Sprintf("%s/%s", assets.FontsPath, fontType))
-	if err != nil {
-		zap.S().Errorw("Unable to read embedded font", zap.Error(err))
-		return nil
+// fontFallbacks are the fonts tried, in order, for glyphs a font doesn't have
+var fontFallbacks = map[string][]string{
+	assets.FontFiraCode:     {assets.FontNotoEmoji},
+	assets.FontFiraCodeBold: {assets.FontNotoEmoji},
+	assets.FontWorkSans:     {assets.FontNotoEmoji},
+	assets.FontNotoEmoji:    {},
+}
+
+type faceKey struct {
+	fontType string
+	size     float64
+}
+
+// FontManager parses every embedded font once and pools faces of them, as a face can't be used by two renders at once.
+// It also holds the colour emoji drawn in place of the fonts' emoji
+type FontManager struct {
+	fonts map[string]*truetype.Font
+	// data are the fonts' files, for embedding them in SVGs
+	data  map[string][]byte
+	emoji *emojiSet
+	pools map[faceKey]*sync.Pool
+	mutex *sync.Mutex
+}
+
+// NewFontManager parses the embedded fonts and emoji, failing if any of them can't be loaded
+func NewFontManager() (*FontManager, error) {
+	fonts := make(map[string]*truetype.Font, len(fontFallbacks))
+	data := make(map[string][]byte, len(fontFallbacks))
+	for fontType := range fontFallbacks {
+		embeddedFont, err := assets.Embedded.ReadFile(fmt.Sprintf("%s/%s", assets.FontsPath, fontType))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read embedded font %s: %w", fontType, err)
+		}
+
+		// We use goki/freetype to address this issue: https://github.com/fogleman/gg/issues/153#issuecomment-1849023145
+		f, err := truetype.Parse(embeddedFont)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse embedded font %s: %w", fontType, err)
+		}
+		fonts[fontType] = f
+		data[fontType] = embeddedFont
 	}
 
-	// We use goki/freetype to address this issue: https://github.com/fogleman/gg/issues/153#issuecomment-1849023145
-	f, err := truetype.Parse(embeddedFont)
+	emoji, err := newEmojiSet()
 	if err != nil {
-		zap.S().Errorw("Unable to parse embedded font", zap.Error(err))
-		return nil
-	}
-
-	ff := truetype.NewFace(
-		f, &truetype.Options{
-			Size:    size,
-			DPI:     72,
-			Hinting: font.HintingNone,
-		},
-	)
-	return ff
+		return nil, err
+	}
+
+	return &FontManager{
+		fonts: fonts,
+		data:  data,
+		emoji: emoji,
+		pools: make(map[faceKey]*sync.Pool),
+		mutex: &sync.Mutex{},
+	}, nil
+}
+
+// newFaces starts a render's use of faces, they're returned to the pool when it's released
+func (m *FontManager) newFaces() *faces {
+	return &faces{manager: m}
+}
+
+func (m *FontManager) getPool(key faceKey) *sync.Pool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	pool, ok := m.pools[key]
+	if !ok {
+		pool = &sync.Pool{
+			New: func() any {
+				return m.newFace(key)
+			},
+		}
+		m.pools[key] = pool
+	}
+
+	return pool
+}
+
+// getFontChain is fontType followed by the fonts its missing glyphs are drawn with
+func getFontChain(fontType string) []string {
+	return append([]string{fontType}, fontFallbacks[fontType]...)
+}
+
+// getFontFor is the font of chain that r is drawn with, the first one that has it
+func (m *FontManager) getFontFor(chain []string, r rune) (fontType string, glyph truetype.Index) {
+	for _, fontType := range chain {
+		if glyph := m.fonts[fontType].Index(r); glyph != 0 {
+			return fontType, glyph
+		}
+	}
+
+	// Nothing has the glyph, so the primary font's missing glyph box is drawn
+	return chain[0], 0
+}
+
+func (m *FontManager) newFace(key faceKey) *fallbackFace {
+	face := &fallbackFace{}
+	for _, fontType := range getFontChain(key.fontType) {
+		f := m.fonts[fontType]
+		face.fonts = append(face.fonts, f)
+		face.faces = append(
+			face.faces, truetype.NewFace(
+				f, &truetype.Options{
+					Size:    key.size,
+					DPI:     72,
+					Hinting: font.HintingNone,
+				},
+			),
+		)
+	}
+
+	return face
+}
+
+// faces hands out the faces used by a single render, which must not be shared with other renders
+type faces struct {
+	manager  *FontManager
+	acquired map[faceKey]*fallbackFace
+}
+
+// get returns a face of fontType at size, falling back to other fonts for glyphs it doesn't have
+func (f *faces) get(fontType string, size float64) font.Face {
+	key := faceKey{fontType: fontType, size: size}
+	if face, ok := f.acquired[key]; ok {
+		return face
+	}
+
+	if _, ok := f.manager.fonts[fontType]; !ok {
+		// Only fonts in fontFallbacks are loaded, so this is a programming error rather than something to recover from
+		panic(fmt.Sprintf("font %s isn't loaded", fontType))
+	}
+
+	if f.acquired == nil {
+		f.acquired = make(map[faceKey]*fallbackFace)
+	}
+	face := f.manager.getPool(key).Get().(*fallbackFace)
+	f.acquired[key] = face
+
+	return face
+}
+
+// release returns the faces to the pool, none of them can be used after
+func (f *faces) release() {
+	for key, face := range f.acquired {
+		f.manager.getPool(key).Put(face)
+	}
+	f.acquired = nil
+}
+
+// fallbackFace draws each glyph with the first font in its chain that has it
+type fallbackFace struct {
+	fonts []*truetype.Font
+	faces []truetype.IndexableFace
+}
+
+func (f *fallbackFace) faceFor(r rune) font.Face {
+	for i, ttf := range f.fonts {
+		if ttf.Index(r) != 0 {
+			return f.faces[i]
+		}
+	}
+
+	// Nothing has the glyph, so draw the primary font's missing glyph box
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (
+	dr image.Rectangle,
+	mask image.Image,
+	maskp image.Point,
+	advance fixed.Int26_6,
+	ok bool,
+) {
+	return f.faceFor(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	return f.faceFor(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	return f.faceFor(r).GlyphAdvance(r)
+}
+
+// Kern only applies between glyphs of the same font
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.faceFor(r0)
+	if face != f.faceFor(r1) {
+		return 0
+	}
+
+	return face.Kern(r0, r1)
+}
+
+// Metrics are the primary font's so lines are spaced the same whatever glyphs they have
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
 }
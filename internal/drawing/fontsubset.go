@@ -0,0 +1,276 @@
+package drawing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/goki/freetype/truetype"
+)
+
+const (
+	// sfntTrueType is the version of fonts with TrueType outlines, the only ones subsetFont can trim
+	sfntTrueType = 0x00010000
+	// headChecksumMagic is what every table's checksum adds up to along with the head's adjustment
+	headChecksumMagic = 0xB1B0AFBA
+)
+
+// Flags of composite glyphs, which are made of other glyphs that have to be kept along with them
+const (
+	compositeArgsAreWords = 0x0001
+	compositeHasScale     = 0x0008
+	compositeMore         = 0x0020
+	compositeHasXYScale   = 0x0040
+	compositeHasTwoByTwo  = 0x0080
+)
+
+// subsetDroppedTables are left out of subsets. Glyph names are the bulk of post, and the layout tables only matter to
+// renderers that substitute and position glyphs, which rasterizing with freetype doesn't, so dropping them also keeps
+// SVGs drawn the same as the images
+var subsetDroppedTables = map[string]bool{
+	"DSIG": true,
+	"GDEF": true,
+	"GPOS": true,
+	"GSUB": true,
+}
+
+type sfntTable struct {
+	tag  string
+	data []byte
+}
+
+// subsetFont trims a TrueType font down to the outlines of glyphs and the glyphs they're made of. Glyph ids are kept,
+// every other glyph is left empty, so the tables mapping characters and metrics to ids don't need rewriting
+func subsetFont(data []byte, glyphs []truetype.Index) ([]byte, error) {
+	tables, err := readSFNTTables(data)
+	if err != nil {
+		return nil, err
+	}
+	head, loca, glyf := tables["head"], tables["loca"], tables["glyf"]
+	if len(head) < 54 || loca == nil || glyf == nil {
+		return nil, fmt.Errorf("font doesn't have TrueType outlines")
+	}
+
+	offsets, err := readLoca(loca, binary.BigEndian.Uint16(head[50:]) == 1)
+	if err != nil {
+		return nil, err
+	}
+	kept, err := getSubsetGlyphs(glyf, offsets, glyphs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Glyphs are aligned to four bytes, which both loca formats can address
+	var subsetGlyf []byte
+	subsetOffsets := make([]uint32, 0, len(offsets))
+	for id := 0; id < len(offsets)-1; id++ {
+		subsetOffsets = append(subsetOffsets, uint32(len(subsetGlyf)))
+		if kept[truetype.Index(id)] {
+			subsetGlyf = append(subsetGlyf, glyf[offsets[id]:offsets[id+1]]...)
+			subsetGlyf = append(subsetGlyf, make([]byte, -len(subsetGlyf)&3)...)
+		}
+	}
+	subsetOffsets = append(subsetOffsets, uint32(len(subsetGlyf)))
+
+	subsetHead := append([]byte(nil), head...)
+	short := len(subsetGlyf)/2 <= 0xffff
+	var subsetLoca []byte
+	if short {
+		binary.BigEndian.PutUint16(subsetHead[50:], 0)
+		for _, offset := range subsetOffsets {
+			subsetLoca = binary.BigEndian.AppendUint16(subsetLoca, uint16(offset/2))
+		}
+	} else {
+		binary.BigEndian.PutUint16(subsetHead[50:], 1)
+		for _, offset := range subsetOffsets {
+			subsetLoca = binary.BigEndian.AppendUint32(subsetLoca, offset)
+		}
+	}
+
+	var subset []sfntTable
+	for tag, table := range tables {
+		switch {
+		case subsetDroppedTables[tag]:
+			continue
+		case tag == "head":
+			table = subsetHead
+		case tag == "loca":
+			table = subsetLoca
+		case tag == "glyf":
+			table = subsetGlyf
+		case tag == "post" && len(table) >= 32:
+			// Version 3 has the same header without any glyph names
+			table = append([]byte(nil), table[:32]...)
+			binary.BigEndian.PutUint32(table, 0x00030000)
+		}
+		subset = append(subset, sfntTable{tag: tag, data: table})
+	}
+
+	return writeSFNT(subset), nil
+}
+
+// readSFNTTables returns the tables of a font by their tag
+func readSFNTTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 || binary.BigEndian.Uint32(data) != sfntTrueType {
+		return nil, fmt.Errorf("font doesn't have TrueType outlines")
+	}
+
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*count {
+		return nil, fmt.Errorf("font's table directory is cut short")
+	}
+
+	tables := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("font's %s table is cut short", record[:4])
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+
+	return tables, nil
+}
+
+// readLoca returns where each glyph starts in glyf, followed by where the last one ends
+func readLoca(loca []byte, long bool) ([]uint32, error) {
+	var offsets []uint32
+	if long {
+		for i := 0; i+4 <= len(loca); i += 4 {
+			offsets = append(offsets, binary.BigEndian.Uint32(loca[i:]))
+		}
+	} else {
+		for i := 0; i+2 <= len(loca); i += 2 {
+			offsets = append(offsets, 2*uint32(binary.BigEndian.Uint16(loca[i:])))
+		}
+	}
+	if len(offsets) < 2 {
+		return nil, fmt.Errorf("font doesn't have any glyphs")
+	}
+
+	return offsets, nil
+}
+
+// getSubsetGlyphs is glyphs along with the glyphs they're made of and .notdef, which every font has to keep
+func getSubsetGlyphs(glyf []byte, offsets []uint32, glyphs []truetype.Index) (map[truetype.Index]bool, error) {
+	kept := map[truetype.Index]bool{0: true}
+	pending := append([]truetype.Index{0}, glyphs...)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if int(id) >= len(offsets)-1 {
+			continue
+		}
+		kept[id] = true
+
+		start, end := offsets[id], offsets[id+1]
+		if start > end || int(end) > len(glyf) {
+			return nil, fmt.Errorf("glyph %d is out of bounds", id)
+		}
+		components, err := getGlyphComponents(glyf[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", id, err)
+		}
+		for _, component := range components {
+			if !kept[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	return kept, nil
+}
+
+// getGlyphComponents is the glyphs a composite glyph is made of, simple glyphs have none
+func getGlyphComponents(glyph []byte) ([]truetype.Index, error) {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil, nil
+	}
+
+	var components []truetype.Index
+	for i := 10; ; {
+		if i+4 > len(glyph) {
+			return nil, fmt.Errorf("composite glyph is cut short")
+		}
+		flags := binary.BigEndian.Uint16(glyph[i:])
+		components = append(components, truetype.Index(binary.BigEndian.Uint16(glyph[i+2:])))
+
+		i += 4
+		if flags&compositeArgsAreWords != 0 {
+			i += 4
+		} else {
+			i += 2
+		}
+		switch {
+		case flags&compositeHasScale != 0:
+			i += 2
+		case flags&compositeHasXYScale != 0:
+			i += 4
+		case flags&compositeHasTwoByTwo != 0:
+			i += 8
+		}
+
+		if flags&compositeMore == 0 {
+			return components, nil
+		}
+	}
+}
+
+// writeSFNT writes tables out as a font, sorted by tag as the format asks for
+func writeSFNT(tables []sfntTable) []byte {
+	sort.Slice(
+		tables, func(i, j int) bool {
+			return tables[i].tag < tables[j].tag
+		},
+	)
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tables) {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	font := binary.BigEndian.AppendUint32(nil, sfntTrueType)
+	font = binary.BigEndian.AppendUint16(font, uint16(len(tables)))
+	font = binary.BigEndian.AppendUint16(font, uint16(searchRange))
+	font = binary.BigEndian.AppendUint16(font, uint16(entrySelector))
+	font = binary.BigEndian.AppendUint16(font, uint16(16*len(tables)-searchRange))
+
+	offset := len(font) + 16*len(tables)
+	headOffset := -1
+	for _, table := range tables {
+		if table.tag == "head" {
+			// The adjustment is worked out once the whole font is written, and left out of the head's checksum
+			headOffset = offset
+			binary.BigEndian.PutUint32(table.data[8:], 0)
+		}
+		font = append(font, table.tag...)
+		font = binary.BigEndian.AppendUint32(font, getSFNTChecksum(table.data))
+		font = binary.BigEndian.AppendUint32(font, uint32(offset))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(table.data)))
+		offset += len(table.data) + (-len(table.data) & 3)
+	}
+	for _, table := range tables {
+		font = append(font, table.data...)
+		font = append(font, make([]byte, -len(table.data)&3)...)
+	}
+
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(font[headOffset+8:], headChecksumMagic-getSFNTChecksum(font))
+	}
+
+	return font
+}
+
+// getSFNTChecksum adds data up as big endian words, padding it with zeros to a whole word
+func getSFNTChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+
+	return sum
+}
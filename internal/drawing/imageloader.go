@@ -0,0 +1,284 @@
+package drawing
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxImageBytes and maxImageDimension keep a bad image from taking up all the memory when it's decoded
+	maxImageBytes     = 2 << 20
+	maxImageDimension = 2048
+	// imageRetryAfter is how long an image that couldn't be loaded is drawn as a placeholder before trying again
+	imageRetryAfter = 10 * time.Minute
+)
+
+// ImageFetcher downloads the images drawn next to team names
+type ImageFetcher interface {
+	Fetch(ctx context.Context, imageURL string) ([]byte, error)
+}
+
+// NewHTTPImageFetcher fetches images over HTTP. When host is set, it replaces the scheme and host of every image URL
+// so a local stand-in can serve them instead of the provider
+func NewHTTPImageFetcher(resty *resty.Client, host string) (ImageFetcher, error) {
+	fetcher := &httpImageFetcher{resty: resty}
+	if host == "" {
+		return fetcher, nil
+	}
+
+	hostURL, err := url.Parse(host)
+	if err != nil || hostURL.Scheme == "" || hostURL.Host == "" {
+		return nil, fmt.Errorf("invalid image host %q", host)
+	}
+	fetcher.host = hostURL
+
+	return fetcher, nil
+}
+
+type httpImageFetcher struct {
+	resty *resty.Client
+	host  *url.URL
+}
+
+func (f *httpImageFetcher) Fetch(ctx context.Context, imageURL string) ([]byte, error) {
+	requestURL, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image url %q: %w", imageURL, err)
+	}
+	if f.host != nil {
+		requestURL.Scheme = f.host.Scheme
+		requestURL.Host = f.host.Host
+	}
+
+	// The body is read here rather than by resty, so images that are too large are never read in full
+	resp, err := f.resty.R().SetContext(ctx).SetDoNotParseResponse(true).Get(requestURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch image %s: %w", requestURL, err)
+	}
+	body := resp.RawBody()
+	defer body.Close()
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch image %s: %s", requestURL, resp.Status())
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image %s: %w", requestURL, err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image %s is more than %d bytes", requestURL, maxImageBytes)
+	}
+
+	return data, nil
+}
+
+type imageCacheEntry struct {
+	imageURL string
+	img      image.Image
+	// err is why the image couldn't be loaded, it's retried once imageRetryAfter has passed since loadedAt
+	err      error
+	loadedAt time.Time
+}
+
+// imageCall is a load in progress that other draws of the same image wait on instead of fetching it again
+type imageCall struct {
+	done  chan struct{}
+	entry *imageCacheEntry
+}
+
+// ImageLoader loads team logos and flags, keeping the maxImages most recently drawn in memory and every image it
+// fetched on disk so they survive restarts. Images are shrunk to the largest size they're drawn at before they're kept
+// in memory, as logos are often far larger
+type ImageLoader struct {
+	fetcher   ImageFetcher
+	cacheDir  string
+	maxImages int
+	maxSize   int
+	entries   map[string]*list.Element
+	order     *list.List
+	inFlight  map[string]*imageCall
+	mutex     *sync.Mutex
+}
+
+// NewImageLoader loads images with fetcher, caching them in cacheDir. An empty cacheDir keeps them in memory only.
+// Images are shrunk to fit in a maxSize square, see GetTeamImageMaxSize
+func NewImageLoader(fetcher ImageFetcher, cacheDir string, maxImages int, maxSize int) (*ImageLoader, error) {
+	if cacheDir != "" {
+		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+			return nil, fmt.Errorf("unable to create image cache directory %s: %w", cacheDir, err)
+		}
+	}
+
+	return &ImageLoader{
+		fetcher:   fetcher,
+		cacheDir:  cacheDir,
+		maxImages: max(maxImages, 1),
+		maxSize:   max(maxSize, 1),
+		entries:   make(map[string]*list.Element),
+		order:     list.New(),
+		inFlight:  make(map[string]*imageCall),
+		mutex:     &sync.Mutex{},
+	}, nil
+}
+
+// Load returns the image at imageURL, images are shared between renders so they must not be drawn on
+func (l *ImageLoader) Load(ctx context.Context, imageURL string) (image.Image, error) {
+	l.mutex.Lock()
+	if element, ok := l.entries[imageURL]; ok {
+		entry := element.Value.(*imageCacheEntry)
+		if entry.err == nil || time.Since(entry.loadedAt) < imageRetryAfter {
+			l.order.MoveToFront(element)
+			l.mutex.Unlock()
+
+			return entry.img, entry.err
+		}
+	}
+
+	if call, ok := l.inFlight[imageURL]; ok {
+		l.mutex.Unlock()
+		select {
+		case <-call.done:
+			return call.entry.img, call.entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &imageCall{done: make(chan struct{})}
+	l.inFlight[imageURL] = call
+	l.mutex.Unlock()
+
+	img, err := l.load(ctx, imageURL)
+	call.entry = &imageCacheEntry{imageURL: imageURL, img: img, err: err, loadedAt: time.Now()}
+
+	l.mutex.Lock()
+	delete(l.inFlight, imageURL)
+	// Loads cut short by the caller say nothing about the image, so they're tried again by the next draw
+	if ctx.Err() == nil {
+		l.add(call.entry)
+	}
+	l.mutex.Unlock()
+	close(call.done)
+
+	return img, err
+}
+
+// add stores an image and evicts the least recently used ones to make room for it, callers must hold the mutex
+func (l *ImageLoader) add(entry *imageCacheEntry) {
+	if element, ok := l.entries[entry.imageURL]; ok {
+		l.order.Remove(element)
+	}
+	l.entries[entry.imageURL] = l.order.PushFront(entry)
+
+	for l.order.Len() > l.maxImages {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*imageCacheEntry).imageURL)
+	}
+}
+
+func (l *ImageLoader) load(ctx context.Context, imageURL string) (image.Image, error) {
+	path := l.getCachePath(imageURL)
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			img, err := decodeImage(data)
+			if err == nil {
+				return l.shrink(img), nil
+			}
+			zap.S().Warnw("Discarding cached image", "url", imageURL, zap.Error(err))
+		}
+	}
+
+	data, err := l.fetcher.Fetch(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image %s: %w", imageURL, err)
+	}
+
+	if path != "" {
+		if err := writeFileAtomic(path, data); err != nil {
+			zap.S().Warnw("Unable to cache image", "url", imageURL, zap.Error(err))
+		}
+	}
+
+	return l.shrink(img), nil
+}
+
+// shrink scales img down to fit in a maxSize square, the full image is still kept on disk
+func (l *ImageLoader) shrink(img image.Image) image.Image {
+	if max(img.Bounds().Dx(), img.Bounds().Dy()) <= l.maxSize {
+		return img
+	}
+
+	return scaleToFit(img, l.maxSize)
+}
+
+// getCachePath is where an image is kept on disk, named by its URL's hash as URLs don't make safe filenames
+func (l *ImageLoader) getCachePath(imageURL string) string {
+	if l.cacheDir == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(imageURL))
+	return filepath.Join(l.cacheDir, hex.EncodeToString(sum[:16]))
+}
+
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+	if config.Width > maxImageDimension || config.Height > maxImageDimension {
+		return nil, fmt.Errorf("image is %dx%d, larger than %d", config.Width, config.Height, maxImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+
+	return img, err
+}
+
+// writeFileAtomic writes through a temporary file so concurrent readers never see half an image
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
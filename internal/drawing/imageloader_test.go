@@ -0,0 +1,247 @@
+package drawing
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+func encodeTestPNG(t *testing.T) []byte {
+	t.Helper()
+
+	return encodeTestPNGSized(t, 4, 4)
+}
+
+func encodeTestPNGSized(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestHTTPImageFetcherFetch(t *testing.T) {
+	logo := encodeTestPNG(t)
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/logo.png":
+					_, _ = w.Write(logo)
+				case "/limit.png":
+					_, _ = w.Write(make([]byte, maxImageBytes))
+				case "/huge.png":
+					// Streamed without a length, so only reading it tells how large it is
+					for i := 0; i < 3; i++ {
+						_, _ = w.Write(make([]byte, maxImageBytes/2+1))
+						w.(http.Flusher).Flush()
+					}
+				default:
+					http.NotFound(w, r)
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	// Image URLs point at the provider, the host stands in for it
+	fetcher, err := NewHTTPImageFetcher(resty.New(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		wantSize int
+		wantErr  bool
+	}{
+		{name: "image", path: "/logo.png", wantSize: len(logo)},
+		{name: "image at the limit", path: "/limit.png", wantSize: maxImageBytes},
+		{name: "image over the limit", path: "/huge.png", wantErr: true},
+		{name: "missing image", path: "/missing.png", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				data, err := fetcher.Fetch(context.Background(), "https://images.example.com"+test.path)
+				if (err != nil) != test.wantErr {
+					t.Fatalf("Fetch() error = %v, want error %t", err, test.wantErr)
+				}
+				if len(data) != test.wantSize {
+					t.Errorf("Fetch() read %d bytes, want %d", len(data), test.wantSize)
+				}
+			},
+		)
+	}
+}
+
+// stubImageFetcher serves images from memory, blocking on the ones it doesn't have until the fetch is given up on
+type stubImageFetcher struct {
+	images map[string][]byte
+	failed map[string]bool
+}
+
+func (f stubImageFetcher) Fetch(ctx context.Context, imageURL string) ([]byte, error) {
+	if data, ok := f.images[imageURL]; ok {
+		return data, nil
+	}
+	if f.failed[imageURL] {
+		return nil, errors.New("not found")
+	}
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestLoadTeamImagesComplete(t *testing.T) {
+	fetcher := stubImageFetcher{
+		images: map[string][]byte{"https://images.example.com/home.png": encodeTestPNG(t)},
+		failed: map[string]bool{"https://images.example.com/missing.png": true},
+	}
+	home := sports.Team{Name: "Home", Logo: "https://images.example.com/home.png"}
+
+	tests := []struct {
+		name   string
+		away   sports.Team
+		cancel bool
+		// inFlight is how long another render's load of the away image has left when this one starts waiting on it
+		inFlight     time.Duration
+		wantImages   int
+		wantComplete bool
+	}{
+		{name: "loaded", away: sports.Team{Name: "Away"}, wantImages: 1, wantComplete: true},
+		{
+			name:         "failed to load",
+			away:         sports.Team{Name: "Away", Logo: "https://images.example.com/missing.png"},
+			wantImages:   1,
+			wantComplete: true,
+		},
+		{
+			name:       "cancelled",
+			away:       sports.Team{Name: "Away", Logo: "https://images.example.com/slow.png"},
+			cancel:     true,
+			wantImages: 1,
+		},
+		{
+			name:       "waited on a load that timed out",
+			away:       sports.Team{Name: "Away", Logo: "https://images.example.com/slow.png"},
+			inFlight:   50 * time.Millisecond,
+			wantImages: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				loader, err := NewImageLoader(fetcher, "", 10, GetTeamImageMaxSize(1080))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := &service{images: loader}
+				// The home logo is loaded up front, so cancelling only leaves out the one that's still loading
+				if _, err := loader.Load(context.Background(), home.Logo); err != nil {
+					t.Fatal(err)
+				}
+
+				if test.inFlight > 0 {
+					startTestLoad(t, loader, test.away.Logo, test.inFlight)
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				if test.cancel {
+					cancel()
+				}
+
+				images, complete := s.loadTeamImages(ctx, []sports.Match{{Home: home, Away: test.away}})
+				if len(images) != test.wantImages {
+					t.Errorf("loaded %d images, want %d", len(images), test.wantImages)
+				}
+				if complete != test.wantComplete {
+					t.Errorf("complete = %t, want %t", complete, test.wantComplete)
+				}
+			},
+		)
+	}
+}
+
+// startTestLoad starts loading imageURL the way another render would, giving up after timeout, and returns once
+// loads of it wait on that one
+func startTestLoad(t *testing.T, loader *ImageLoader, imageURL string, timeout time.Duration) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+	go func() {
+		_, _ = loader.Load(ctx, imageURL)
+	}()
+
+	for {
+		loader.mutex.Lock()
+		_, ok := loader.inFlight[imageURL]
+		loader.mutex.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestImageLoaderShrinks(t *testing.T) {
+	fetcher := stubImageFetcher{
+		images: map[string][]byte{
+			"https://images.example.com/small.png": encodeTestPNGSized(t, 4, 4),
+			"https://images.example.com/large.png": encodeTestPNGSized(t, 2000, 1000),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		imageURL   string
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "smaller than it's drawn", imageURL: "https://images.example.com/small.png", wantWidth: 4, wantHeight: 4},
+		{name: "larger than it's drawn", imageURL: "https://images.example.com/large.png", wantWidth: 80, wantHeight: 40},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				// 1080 pixel tall images draw team images at most 40 pixels, and SVGs keep twice that
+				loader, err := NewImageLoader(fetcher, t.TempDir(), 10, GetTeamImageMaxSize(1080))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Images read back from disk are shrunk as well
+				for _, source := range []string{"fetched", "cached on disk"} {
+					img, err := loader.Load(context.Background(), test.imageURL)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if bounds := img.Bounds(); bounds.Dx() != test.wantWidth || bounds.Dy() != test.wantHeight {
+						t.Errorf("%s image is %dx%d, want %dx%d", source, bounds.Dx(), bounds.Dy(), test.wantWidth, test.wantHeight)
+					}
+
+					loader.mutex.Lock()
+					loader.entries = make(map[string]*list.Element)
+					loader.order.Init()
+					loader.mutex.Unlock()
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,110 @@
+package drawing
+
+import "math"
+
+// AspectRatio is the shape of frame images, written the way fc:frame:image:aspect_ratio takes it
+type AspectRatio string
+
+const (
+	AspectRatioWide   AspectRatio = "1.91:1"
+	AspectRatioSquare AspectRatio = "1:1"
+)
+
+// ParseAspectRatio returns the aspect ratio written as s, ok is false for ones frames don't support
+func ParseAspectRatio(s string) (AspectRatio, bool) {
+	switch ratio := AspectRatio(s); ratio {
+	case AspectRatioWide, AspectRatioSquare:
+		return ratio, true
+	}
+
+	return "", false
+}
+
+// getCanvasSize is how big images with the aspect ratio are when they're height pixels tall
+func (r AspectRatio) getCanvasSize(height int) (int, int) {
+	if r == AspectRatioSquare {
+		return height, height
+	}
+
+	return int(math.Round(float64(height) * 1.91)), height
+}
+
+// Sizes are designed for images baseCanvasSize pixels on their short side, and scaled to fit others
+const (
+	baseCanvasSize float64 = 1080
+	headerHeight   float64 = 180
+	footerHeight   float64 = 60
+	cardHeight     float64 = 130
+	// maxCardGap is the most space a theme can put between rows of cards, rows are counted as if it were used
+	maxCardGap   float64 = 10
+	minCardWidth float64 = 900
+	// textMargin keeps centred lines of text off the sides of images
+	textMargin float64 = 90
+	// singleColumnCardScale shrinks cards when there's only one column, so tall images fit more matches on a page
+	singleColumnCardScale = 0.85
+)
+
+// grid lays matches out in as many columns as fit the canvas's width and as many rows as fit its height
+type grid struct {
+	width  float64
+	height float64
+	// unit scales sizes designed for baseCanvasSize to the canvas
+	unit    float64
+	columns int
+	rows    int
+	// cardScale scales what's drawn inside cards
+	cardScale float64
+}
+
+func newGrid(width, height int) grid {
+	g := grid{width: float64(width), height: float64(height)}
+	g.unit = min(g.width, g.height) / baseCanvasSize
+	g.columns = max(1, int(g.width/(minCardWidth*g.unit)))
+
+	g.cardScale = g.unit
+	if g.columns == 1 {
+		g.cardScale *= singleColumnCardScale
+	}
+
+	available := g.height - (headerHeight+footerHeight)*g.unit
+	gap := maxCardGap * g.unit
+	g.rows = max(1, int((available+gap)/(g.cardHeight()+gap)))
+
+	return g
+}
+
+// perPage is how many matches fit on a page
+func (g grid) perPage() int {
+	return g.columns * g.rows
+}
+
+func (g grid) cardHeight() float64 {
+	return cardHeight * g.cardScale
+}
+
+// getCard returns where the i'th card on a page goes and how wide it is, margin and gap are unscaled theme spacing
+func (g grid) getCard(i int, margin, gap float64) (x, y, width float64) {
+	margin *= g.unit
+	gap *= g.unit
+	cellWidth := (g.width - margin) / float64(g.columns)
+
+	x = margin + float64(i%g.columns)*cellWidth
+	y = headerHeight*g.unit + float64(i/g.columns)*(g.cardHeight()+gap)
+
+	return x, y, cellWidth - margin
+}
+
+// scale scales a size designed for baseCanvasSize to the canvas
+func (g grid) scale(size float64) float64 {
+	return size * g.unit
+}
+
+// scaleCard scales a size of something drawn in a card
+func (g grid) scaleCard(size float64) float64 {
+	return size * g.cardScale
+}
+
+// textWidth is how wide a line of text centred on the canvas can be without running into its margins
+func (g grid) textWidth() float64 {
+	return g.width - 2*g.scale(textMargin)
+}
@@ -0,0 +1,166 @@
+package drawing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// Box score layout for the match detail image, sizes are designed for baseCanvasSize like the grid
+const (
+	// boxScoreStart and boxScoreNameShare are fractions of the image's height and width
+	boxScoreStart         float64 = 0.4
+	boxScoreNameShare     float64 = 0.45
+	boxScoreRowHeight     float64 = 130
+	boxScoreMaxColWidth   float64 = 150
+	boxScoreFontSize      float64 = 60
+	boxScoreLabelFontSize float64 = 40
+	boxScoreHeaderHeight  float64 = 80
+	matchLeagueFontSize   float64 = 60
+	matchLineSpacing      float64 = 70
+	messageFontSize       float64 = 60
+)
+
+// DrawMatch draws a match's box score, match is nil once it's no longer available
+func (s *service) DrawMatch(theme Theme, layout grid, match *sports.Match) (*scene, error) {
+	if match == nil {
+		// Matches drop out of the cache once they're no longer live
+		return s.drawMessage(theme, layout, "Match is no longer available")
+	}
+
+	return s.drawMatch(theme, layout, *match)
+}
+
+func (s *service) drawMatch(theme Theme, layout grid, match sports.Match) (*scene, error) {
+	faces := s.fonts.newFaces()
+	defer faces.release()
+
+	sc := newScene(layout.width, layout.height, theme.Colors.Background)
+
+	// Lines are centred and shrunk to fit narrow images
+	drawLine := func(text string, size float64, y float64) {
+		size = faces.fitSize(theme.Fonts.Regular, layout.scale(size), text, layout.textWidth())
+		faces.drawString(sc, theme.Fonts.Regular, size, theme.Colors.Text, text, layout.width/2, y, 0.5, 0.5)
+	}
+
+	// League and season make up the title, with the status under them
+	titleY := layout.scale(headerHeight) / 2
+	statusY := layout.height / 4
+	lineSpacing := layout.scale(matchLineSpacing)
+	drawLine(match.League, matchLeagueFontSize, titleY)
+	drawLine(match.Season, theme.Fonts.Footer, titleY+lineSpacing)
+	drawLine(getMatchStatus(match, time.Now()), theme.Fonts.Subtitle, statusY)
+	if !match.StartAt.IsZero() {
+		drawLine(
+			fmt.Sprintf("Started %s", match.StartAt.Format("Jan 2 15:04 MST")),
+			theme.Fonts.Footer,
+			statusY+lineSpacing,
+		)
+	}
+
+	drawBoxScore(sc, faces, theme, layout, match)
+
+	return sc, nil
+}
+
+// drawBoxScore draws a table with a column per period and a row per team
+func drawBoxScore(sc *scene, faces *faces, theme Theme, layout grid, match sports.Match) {
+	columns := getScoreColumns(match.Score)
+	fontSize := layout.scale(boxScoreFontSize)
+	fonts := newScoreFonts(faces, theme, fontSize, layout.scale(boxScoreLabelFontSize), 0)
+
+	margin := layout.scale(theme.Spacing.Margin)
+	startX := margin
+	startY := layout.height * boxScoreStart
+	width := layout.width - 2*margin
+	rowHeight := layout.scale(boxScoreRowHeight)
+	serveRadius := layout.scale(serveIndicatorRadius)
+
+	// Spread the columns over the space the names leave, without letting them get wider than boxScoreMaxColWidth
+	if len(columns) > 0 {
+		fonts.columnWidth = min(
+			layout.scale(boxScoreMaxColWidth),
+			width*(1-boxScoreNameShare)/float64(len(columns)),
+		)
+		fonts.totalColumnWidth = fonts.columnWidth
+	}
+	scoresStartX := startX + width - getScoreColumnsWidth(fonts, columns)
+
+	drawCard(sc, theme, startX, startY, width, rowHeight*2)
+	if theme.Card.HighlightWinner {
+		drawLeaderHighlight(sc, theme, match.Score, startX, startY, width, rowHeight*2, startY, rowHeight)
+	}
+
+	// Rows are placed by their baselines
+	homeY := startY + rowHeight/2 + fontSize/3
+	awayY := homeY + rowHeight
+
+	// Names get whatever room the scores and serve indicator leave
+	nameX := startX + margin
+	nameWidth := scoresStartX - nameX - 4*serveRadius
+	faces.drawTeamNames(
+		sc,
+		theme.Fonts.Regular,
+		fontSize,
+		theme.Colors.CardText,
+		match,
+		nameX,
+		homeY,
+		awayY,
+		nameWidth,
+	)
+
+	faces.drawScoreColumns(sc, fonts, theme.Colors.CardText, columns, scoresStartX, homeY, awayY)
+
+	// Period labels sit above the table, on the background
+	labelY := startY - layout.scale(boxScoreHeaderHeight)/3
+	faces.drawScoreLabels(sc, fonts, theme.Colors.Text, columns, scoresStartX, labelY)
+
+	if match.Score.Tennis != nil && match.Score.Tennis.Server != sports.NoSide {
+		serverY := homeY
+		if match.Score.Tennis.Server == sports.AwaySide {
+			serverY = awayY
+		}
+		drawServeIndicator(sc, theme, scoresStartX-2*serveRadius, serverY-fontSize/3, 1.5*serveRadius)
+	}
+
+	sc.add(
+		lineShape{
+			x1:          startX,
+			y1:          startY + rowHeight,
+			x2:          startX + width,
+			y2:          startY + rowHeight,
+			stroke:      theme.Colors.CardBorder,
+			strokeWidth: 2,
+		},
+	)
+}
+
+// getMatchStatus describes how far along a match is, e.g. "2nd quarter - 7'"
+func getMatchStatus(match sports.Match, now time.Time) string {
+	status := match.StatusMore
+	if status == "" {
+		status = match.Status
+	}
+
+	if match.Status == sports.MatchStatusInProgress && !match.CurrentPeriodStartAt.IsZero() {
+		elapsed := now.Sub(match.CurrentPeriodStartAt)
+		status = fmt.Sprintf("%s - %d'", status, int(elapsed.Minutes()))
+	}
+
+	return strings.TrimSpace(status)
+}
+
+// drawMessage draws a single centered line of text
+func (s *service) drawMessage(theme Theme, layout grid, message string) (*scene, error) {
+	faces := s.fonts.newFaces()
+	defer faces.release()
+
+	sc := newScene(layout.width, layout.height, theme.Colors.Background)
+	size := faces.fitSize(theme.Fonts.Regular, layout.scale(messageFontSize), message, layout.textWidth())
+	faces.drawString(sc, theme.Fonts.Regular, size, theme.Colors.Text, message, layout.width/2, layout.height/2, 0.5, 0.5)
+
+	return sc, nil
+}
@@ -0,0 +1,111 @@
+package drawing
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+const (
+	// nameMinFontScale is how far names are shrunk to fit before falling back to shorter ones
+	nameMinFontScale = 0.75
+	// nameFontStep is how much smaller each attempt at fitting a name is
+	nameFontStep = 2
+	ellipsis     = "…"
+)
+
+// fittedName is a team's name as it fits in the space it's drawn in
+type fittedName struct {
+	text string
+	size float64
+}
+
+// drawTeamNames draws both teams' names fitted to width, homeY and awayY are their baselines
+func (f *faces) drawTeamNames(
+	sc *scene,
+	fontType string,
+	size float64,
+	fill color.Color,
+	match sports.Match,
+	x, homeY, awayY, width float64,
+) {
+	home := f.fitName(fontType, size, match.Home, width)
+	away := f.fitName(fontType, size, match.Away, width)
+	f.drawString(sc, fontType, home.size, fill, home.text, x, homeY, 0, 0)
+	f.drawString(sc, fontType, away.size, fill, away.text, x, awayY, 0, 0)
+}
+
+// fitName finds how to draw a team's name in width: its name if it fits, then its short name, then its code, all at
+// full size so names line up with the rest of the card. Only then are the names shrunk down to nameMinFontScale, and
+// finally whichever is shortest cut off with an ellipsis
+func (f *faces) fitName(fontType string, size float64, team sports.Team, width float64) fittedName {
+	names := getTeamNames(team)
+	fits := func(text string, size float64) bool {
+		return f.measureString(fontType, size, text) <= width
+	}
+
+	for _, name := range names {
+		if fits(name, size) {
+			return fittedName{text: name, size: size}
+		}
+	}
+	if code := strings.TrimSpace(team.Code); code != "" && fits(code, size) {
+		return fittedName{text: code, size: size}
+	}
+
+	minSize := size * nameMinFontScale
+	for _, name := range names {
+		for shrunk := size - nameFontStep; shrunk >= minSize; shrunk -= nameFontStep {
+			if fits(name, shrunk) {
+				return fittedName{text: name, size: shrunk}
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return fittedName{size: size}
+	}
+
+	return fittedName{
+		text: ellipsize(names[len(names)-1], func(text string) bool { return fits(text, minSize) }),
+		size: minSize,
+	}
+}
+
+// getTeamNames are a team's names from longest to shortest, skipping ones that are missing or the same
+func getTeamNames(team sports.Team) []string {
+	var names []string
+	for _, name := range []string{team.Name, team.ShortName} {
+		name = strings.TrimSpace(name)
+		if name != "" && (len(names) == 0 || names[len(names)-1] != name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ellipsize cuts text down to the most characters that fit followed by an ellipsis
+func ellipsize(text string, fits func(string) bool) string {
+	runes := []rune(text)
+
+	// Binary search for the longest prefix that fits
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if fits(getEllipsized(runes[:mid])) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return getEllipsized(runes[:low])
+}
+
+func getEllipsized(runes []rune) string {
+	// Don't leave a space or half an emoji sequence dangling before the ellipsis
+	text := strings.TrimRight(string(runes), " \u200d\ufe0f")
+	return text + ellipsis
+}
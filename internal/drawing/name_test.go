@@ -0,0 +1,88 @@
+package drawing
+
+import (
+	"testing"
+
+	"github.com/welps/go-frames-scores/assets"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+func newTestFontManager(t testing.TB) *FontManager {
+	t.Helper()
+
+	fonts, err := NewFontManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fonts
+}
+
+// TestMeasureString pins how wide names are, the widths fitName is tested with below depend on them
+func TestMeasureString(t *testing.T) {
+	faces := newTestFontManager(t).newFaces()
+	defer faces.release()
+
+	tests := []struct {
+		text  string
+		size  float64
+		width float64
+	}{
+		{text: "Manchester United", size: 40, width: 418},
+		{text: "Man Utd", size: 40, width: 172},
+		{text: "Man Utd", size: 30, width: 129},
+		{text: "MUN", size: 40, width: 73},
+		{text: "Rafael Nadal", size: 40, width: 295},
+		{text: ellipsis, size: 30, width: 18},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.text, func(t *testing.T) {
+				if got := faces.measureString(assets.FontFiraCode, test.size, test.text); got != test.width {
+					t.Errorf("measureString(%q, %g) = %g, want %g", test.text, test.size, got, test.width)
+				}
+			},
+		)
+	}
+}
+
+func TestFitName(t *testing.T) {
+	faces := newTestFontManager(t).newFaces()
+	defer faces.release()
+
+	united := sports.Team{Name: "Manchester United", ShortName: "Man Utd", Code: "MUN"}
+	noCode := sports.Team{Name: "Manchester United", ShortName: "Man Utd"}
+	tests := []struct {
+		name     string
+		team     sports.Team
+		width    float64
+		wantText string
+		wantSize float64
+	}{
+		{name: "name fits", team: united, width: 420, wantText: "Manchester United", wantSize: 40},
+		{name: "short name fits", team: united, width: 200, wantText: "Man Utd", wantSize: 40},
+		{name: "code before shrinking", team: united, width: 150, wantText: "MUN", wantSize: 40},
+		{name: "code", team: united, width: 80, wantText: "MUN", wantSize: 40},
+		{name: "shrunk without a code", team: noCode, width: 150, wantText: "Man Utd", wantSize: 34},
+		{name: "ellipsized without a code", team: noCode, width: 100, wantText: "Man…", wantSize: 30},
+		{name: "ellipsized when the code doesn't fit", team: united, width: 60, wantText: "Ma…", wantSize: 30},
+		{
+			name:     "code without a short name",
+			team:     sports.Team{Name: "Rafael Nadal", Code: "NAD"},
+			width:    130,
+			wantText: "NAD",
+			wantSize: 40,
+		},
+		{name: "no names", team: sports.Team{}, width: 100, wantText: "", wantSize: 40},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				got := faces.fitName(assets.FontFiraCode, 40, test.team, test.width)
+				if got.text != test.wantText || got.size != test.wantSize {
+					t.Errorf("fitName() = %q at %g, want %q at %g", got.text, got.size, test.wantText, test.wantSize)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,90 @@
+package drawing
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	xdraw "golang.org/x/image/draw"
+)
+
+// drawScene draws a scene as a raster image with gg
+func (m *FontManager) drawScene(sc *scene) image.Image {
+	faces := m.newFaces()
+	defer faces.release()
+
+	imageContext := gg.NewContext(int(sc.width), int(sc.height))
+	imageContext.SetColor(sc.background)
+	imageContext.Clear()
+
+	// Clipping masks the whole image, so it's only redone when the clip changes rather than for every shape
+	var clip *clipShape
+	for _, item := range sc.items {
+		if item.clip != clip {
+			imageContext.ResetClip()
+			if item.clip != nil {
+				drawRectPath(imageContext, item.clip.x, item.clip.y, item.clip.width, item.clip.height, item.clip.radius)
+				imageContext.Clip()
+			}
+			clip = item.clip
+		}
+
+		faces.drawShape(imageContext, item.shape)
+	}
+
+	return imageContext.Image()
+}
+
+func (f *faces) drawShape(imageContext *gg.Context, shape shape) {
+	switch shape := shape.(type) {
+	case rectShape:
+		drawRectPath(imageContext, shape.x, shape.y, shape.width, shape.height, shape.radius)
+		fillAndStroke(imageContext, shape.fill, shape.stroke, shape.strokeWidth)
+	case circleShape:
+		imageContext.DrawCircle(shape.x, shape.y, shape.radius)
+		fillAndStroke(imageContext, shape.fill, shape.stroke, shape.strokeWidth)
+	case lineShape:
+		imageContext.SetColor(shape.stroke)
+		imageContext.SetLineWidth(shape.strokeWidth)
+		imageContext.DrawLine(shape.x1, shape.y1, shape.x2, shape.y2)
+		imageContext.Stroke()
+	case textShape:
+		imageContext.SetFontFace(f.get(shape.fontType, shape.size))
+		imageContext.SetColor(shape.fill)
+		imageContext.DrawString(shape.text, shape.x, shape.y)
+	case imageShape:
+		// Resampling first looks much better than letting gg scale images while drawing them
+		width, height := int(math.Round(shape.width)), int(math.Round(shape.height))
+		img := shape.img
+		if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+			scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+			xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+			img = scaled
+		}
+		imageContext.DrawImage(img, int(math.Round(shape.x)), int(math.Round(shape.y)))
+	}
+}
+
+func drawRectPath(imageContext *gg.Context, x, y, width, height, radius float64) {
+	if radius > 0 {
+		imageContext.DrawRoundedRectangle(x, y, width, height, radius)
+		return
+	}
+
+	imageContext.DrawRectangle(x, y, width, height)
+}
+
+// fillAndStroke fills and then strokes the current path, skipping whichever is nil
+func fillAndStroke(imageContext *gg.Context, fill, stroke color.Color, strokeWidth float64) {
+	if fill != nil {
+		imageContext.SetColor(fill)
+		imageContext.FillPreserve()
+	}
+	if stroke != nil && strokeWidth > 0 {
+		imageContext.SetColor(stroke)
+		imageContext.SetLineWidth(strokeWidth)
+		imageContext.StrokePreserve()
+	}
+	imageContext.ClearPath()
+}
@@ -0,0 +1,98 @@
+package drawing
+
+import (
+	"image"
+	"image/color"
+)
+
+// scene is an image as the boxes, text and images it's made of, so it can be drawn as a raster with gg or written out
+// as SVG. Shapes are drawn in the order they're added, later ones on top
+type scene struct {
+	width      float64
+	height     float64
+	background color.Color
+	items      []sceneItem
+	// clip is applied to shapes as they're added, see withClip
+	clip *clipShape
+	// partial is set when images were left out only because they took too long to load, so the scene shouldn't be
+	// kept as if it were the final image
+	partial bool
+}
+
+type sceneItem struct {
+	shape shape
+	clip  *clipShape
+}
+
+// shape is one of the *Shape types below
+type shape interface {
+	isShape()
+}
+
+// rectShape is a box with optionally rounded corners. Its fill and stroke can be nil to leave them out
+type rectShape struct {
+	x, y, width, height float64
+	radius              float64
+	fill                color.Color
+	stroke              color.Color
+	strokeWidth         float64
+}
+
+type circleShape struct {
+	x, y, radius float64
+	fill         color.Color
+	stroke       color.Color
+	strokeWidth  float64
+}
+
+type lineShape struct {
+	x1, y1, x2, y2 float64
+	stroke         color.Color
+	strokeWidth    float64
+}
+
+// textShape is a run of text in one font, y is its baseline
+type textShape struct {
+	x, y     float64
+	text     string
+	fontType string
+	size     float64
+	fill     color.Color
+}
+
+// imageShape is an image scaled into a box
+type imageShape struct {
+	img                 image.Image
+	x, y, width, height float64
+}
+
+// clipShape is the box, rounded like a card, that shapes are cut off at
+type clipShape struct {
+	x, y, width, height float64
+	radius              float64
+}
+
+func (rectShape) isShape()   {}
+func (circleShape) isShape() {}
+func (lineShape) isShape()   {}
+func (textShape) isShape()   {}
+func (imageShape) isShape()  {}
+
+func newScene(width, height float64, background color.Color) *scene {
+	return &scene{width: width, height: height, background: background}
+}
+
+func (s *scene) add(shape shape) {
+	s.items = append(s.items, sceneItem{shape: shape, clip: s.clip})
+}
+
+// withClip cuts off everything draw adds at clip. Clips don't nest, the innermost one wins
+func (s *scene) withClip(clip clipShape, draw func()) {
+	outer := s.clip
+	s.clip = &clip
+	defer func() {
+		s.clip = outer
+	}()
+
+	draw()
+}
@@ -0,0 +1,219 @@
+package drawing
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// scoreColumn is a single column of a scoreboard, e.g. a quarter, a set or the totals
+type scoreColumn struct {
+	label string
+	home  string
+	away  string
+	// homeSuperscript and awaySuperscript are drawn small and raised after the score, like a tiebreak in tennis
+	homeSuperscript string
+	awaySuperscript string
+	total           bool
+}
+
+// fontStyle is a font at a size
+type fontStyle struct {
+	fontType string
+	size     float64
+}
+
+// scoreFonts are the fonts and column widths used to draw score columns at a given size
+type scoreFonts struct {
+	label            fontStyle
+	score            fontStyle
+	total            fontStyle
+	superscript      fontStyle
+	size             float64
+	columnWidth      float64
+	totalColumnWidth float64
+}
+
+func newScoreFonts(faces *faces, theme Theme, size float64, labelSize float64, padding float64) scoreFonts {
+	fonts := scoreFonts{
+		label:       fontStyle{fontType: theme.Fonts.Regular, size: labelSize},
+		score:       fontStyle{fontType: theme.Fonts.Regular, size: size},
+		total:       fontStyle{fontType: theme.Fonts.Bold, size: size},
+		superscript: fontStyle{fontType: theme.Fonts.Regular, size: size / 2},
+		size:        size,
+	}
+
+	// Leave room for two digits and a superscript in every column, and three digits in the totals
+	digitsWidth := faces.measureString(fonts.score.fontType, fonts.score.size, "00")
+	superscriptWidth := faces.measureString(fonts.superscript.fontType, fonts.superscript.size, "0")
+	fonts.columnWidth = digitsWidth + superscriptWidth + padding
+
+	totalDigitsWidth := faces.measureString(fonts.total.fontType, fonts.total.size, "000")
+	fonts.totalColumnWidth = totalDigitsWidth + padding
+
+	return fonts
+}
+
+func (f scoreFonts) width(column scoreColumn) float64 {
+	if column.total {
+		return f.totalColumnWidth
+	}
+
+	return f.columnWidth
+}
+
+// getScoreColumns turns a score into the columns drawn for it, ending with the totals when there are any
+func getScoreColumns(score sports.Score) []scoreColumn {
+	if score.Tennis != nil {
+		return getTennisScoreColumns(score.Tennis)
+	}
+
+	labels := score.GetPeriodLabels()
+	columns := make([]scoreColumn, 0, len(labels)+1)
+	for i, label := range labels {
+		columns = append(
+			columns, scoreColumn{
+				label: label,
+				home:  getPeriodScore(score.Home, i),
+				away:  getPeriodScore(score.Away, i),
+			},
+		)
+	}
+
+	if hasTotals(score) {
+		columns = append(columns, scoreColumn{label: "T", home: score.HomeTotal, away: score.AwayTotal, total: true})
+	}
+
+	return columns
+}
+
+// getTennisScoreColumns shows games per set with tiebreak points, the current game and the sets won
+func getTennisScoreColumns(tennis *sports.TennisScore) []scoreColumn {
+	columns := make([]scoreColumn, 0, len(tennis.Sets)+2)
+	for i, set := range tennis.Sets {
+		columns = append(
+			columns, scoreColumn{
+				label:           strconv.Itoa(i + 1),
+				home:            strconv.Itoa(set.HomeGames),
+				away:            strconv.Itoa(set.AwayGames),
+				homeSuperscript: set.HomeTiebreak,
+				awaySuperscript: set.AwayTiebreak,
+			},
+		)
+	}
+
+	if tennis.HomePoint != "" || tennis.AwayPoint != "" {
+		columns = append(columns, scoreColumn{label: "PT", home: tennis.HomePoint, away: tennis.AwayPoint})
+	}
+
+	columns = append(
+		columns, scoreColumn{
+			label: "Sets",
+			home:  strconv.Itoa(tennis.HomeSets),
+			away:  strconv.Itoa(tennis.AwaySets),
+			total: true,
+		},
+	)
+
+	return columns
+}
+
+// getScoreColumnsWidth is how much horizontal space columns take up
+func getScoreColumnsWidth(fonts scoreFonts, columns []scoreColumn) float64 {
+	width := 0.0
+	for _, column := range columns {
+		width += fonts.width(column)
+	}
+
+	return width
+}
+
+// fitScoreColumns leaves out the earliest periods until columns fit in width. The totals are always kept, even when
+// they don't fit on their own
+func fitScoreColumns(fonts scoreFonts, columns []scoreColumn, width float64) []scoreColumn {
+	for len(columns) > 0 && !columns[0].total && getScoreColumnsWidth(fonts, columns) > width {
+		columns = columns[1:]
+	}
+
+	return columns
+}
+
+// drawScoreLabels draws the label of each column starting at startX, y is the labels' baseline
+func (f *faces) drawScoreLabels(
+	sc *scene,
+	fonts scoreFonts,
+	fill color.Color,
+	columns []scoreColumn,
+	startX, y float64,
+) {
+	x := startX
+	for _, column := range columns {
+		f.drawString(sc, fonts.label.fontType, fonts.label.size, fill, column.label, x+fonts.width(column)/2, y, 0.5, 0)
+		x += fonts.width(column)
+	}
+}
+
+// drawScoreColumns draws the scores of each column starting at startX, homeY and awayY are baselines
+func (f *faces) drawScoreColumns(
+	sc *scene,
+	fonts scoreFonts,
+	fill color.Color,
+	columns []scoreColumn,
+	startX, homeY, awayY float64,
+) {
+	x := startX
+	for _, column := range columns {
+		centerX := x + fonts.width(column)/2
+
+		scoreFont := fonts.score
+		if column.total {
+			scoreFont = fonts.total
+		}
+		f.drawScoreWithSuperscript(sc, fonts, scoreFont, fill, column.home, column.homeSuperscript, centerX, homeY)
+		f.drawScoreWithSuperscript(sc, fonts, scoreFont, fill, column.away, column.awaySuperscript, centerX, awayY)
+
+		x += fonts.width(column)
+	}
+}
+
+func (f *faces) drawScoreWithSuperscript(
+	sc *scene,
+	fonts scoreFonts,
+	scoreFont fontStyle,
+	fill color.Color,
+	score, superscript string,
+	centerX, y float64,
+) {
+	f.drawString(sc, scoreFont.fontType, scoreFont.size, fill, score, centerX, y, 0.5, 0)
+	if superscript == "" {
+		return
+	}
+
+	scoreWidth := f.measureString(scoreFont.fontType, scoreFont.size, score)
+	f.drawString(
+		sc,
+		fonts.superscript.fontType,
+		fonts.superscript.size,
+		fill,
+		superscript,
+		centerX+scoreWidth/2+2,
+		y-fonts.size*0.4,
+		0,
+		0,
+	)
+}
+
+// drawServeIndicator draws a ball next to the row of whoever is serving
+func drawServeIndicator(sc *scene, theme Theme, x, y, radius float64) {
+	sc.add(
+		circleShape{
+			x:           x,
+			y:           y,
+			radius:      radius,
+			fill:        theme.Colors.Live,
+			stroke:      theme.Colors.CardText,
+			strokeWidth: 1,
+		},
+	)
+}
@@ -0,0 +1,106 @@
+package drawing
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// TestDrawSportInnings draws baseball cards with more innings than fit next to the team names
+func TestDrawSportInnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		aspectRatio AspectRatio
+		innings     int
+	}{
+		{name: "nine innings", aspectRatio: AspectRatioWide, innings: 9},
+		{name: "extra innings", aspectRatio: AspectRatioWide, innings: 12},
+		{name: "marathon", aspectRatio: AspectRatioWide, innings: 25},
+		{name: "extra innings on a square image", aspectRatio: AspectRatioSquare, innings: 12},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				s := newTestService(t)
+				sport, _ := sports.GetSport(sports.Baseball)
+				theme := s.themes.get("", sport.Slug)
+				home := sports.Team{Name: "Los Angeles Dodgers", Code: "LAD"}
+				match := sports.Match{
+					ID:       1,
+					GameType: sports.Baseball,
+					Home:     home,
+					Away:     sports.Team{Name: "San Diego Padres", Code: "SD"},
+					Score:    getTestInningsScore(test.innings),
+					Status:   sports.MatchStatusInProgress,
+				}
+				sc, err := s.drawSport(
+					context.Background(),
+					theme,
+					s.getGrid(test.aspectRatio),
+					sport,
+					[]sports.Match{match},
+					0,
+					matchesAvailable,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				faces := s.fonts.newFaces()
+				defer faces.release()
+
+				// Labels are the only numbers other than the runs, which are all 0, drawn after the names
+				var name *textShape
+				var labels []textShape
+				for _, item := range sc.items {
+					text, ok := item.shape.(textShape)
+					if !ok {
+						continue
+					}
+					inning, err := strconv.Atoi(text.text)
+					switch {
+					case text.text == home.Name || text.text == home.Code:
+						name = &text
+					case name != nil && (text.text == "T" || (err == nil && inning > 0)):
+						labels = append(labels, text)
+					}
+				}
+				if name == nil {
+					t.Fatalf("%s isn't drawn at full length or as %s", home.Name, home.Code)
+				}
+				if len(labels) < 2 {
+					t.Fatalf("drew labels %v", labels)
+				}
+
+				nameEndX := name.x + faces.measureString(name.fontType, name.size, name.text)
+				if nameEndX > labels[0].x {
+					t.Errorf("%q ends at %.0f, past the first score column at %.0f", name.text, nameEndX, labels[0].x)
+				}
+				// The latest innings are kept, in order, followed by the totals
+				for i, label := range labels {
+					want := strconv.Itoa(test.innings - len(labels) + 2 + i)
+					if i == len(labels)-1 {
+						want = "T"
+					}
+					if label.text != want {
+						t.Errorf("label %d is %q, want %q", i, label.text, want)
+					}
+				}
+			},
+		)
+	}
+}
+
+// getTestInningsScore is a scoreless baseball game that's gone on for innings
+func getTestInningsScore(innings int) sports.Score {
+	score := sports.Score{HomeTotal: "0", AwayTotal: "0"}
+	for i := 0; i < innings; i++ {
+		score.Periods = append(score.Periods, strconv.Itoa(i+1))
+		score.Home = append(score.Home, "0")
+		score.Away = append(score.Away, "0")
+	}
+
+	return score
+}
@@ -3,200 +3,774 @@ package drawing
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/fogleman/gg"
 	"github.com/samber/lo"
-	"github.com/welps/go-frames-scores/assets"
 	"github.com/welps/go-frames-scores/internal/sports"
-	"image/png"
+	"image"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const generatedDirectory = "generated"
+
+// Match card layout, designed for baseCanvasSize like the grid. The margins, gaps and font sizes come from the theme
 const (
-	frameImageX        = 2062
-	frameImageY        = 1080
-	generatedDirectory = "generated"
+	matchNumberWidth     float64 = 60
+	matchNumberFontSize  float64 = 40
+	periodLabelHeight    float64 = 26
+	cardPadding          float64 = 20
+	scoreColumnPadding   float64 = 16
+	serveIndicatorRadius float64 = 10
+	// minTeamNameWidth is kept for team names however many periods a match has, the earliest are left out instead
+	minTeamNameWidth  float64 = 160
+	rootEmojiFontSize float64 = 96
 )
 
-var assetMapping = map[int]string{
-	0: "root.png",
-	1: "tennis.png",
-	2: "basketball.png",
+const (
+	rootScreen  = "root"
+	matchScreen = "match"
+)
+
+// matchesAvailability is whether a sport's matches are up to date when drawing them
+type matchesAvailability int
+
+const (
+	matchesAvailable matchesAvailability = iota
+	// matchesStale matches are left over from an earlier update because the last one failed
+	matchesStale
+	// matchesUnavailable means no update has succeeded yet, so there are no matches to draw
+	matchesUnavailable
+)
+
+// Style is how images look, empty fields use the defaults
+type Style struct {
+	// Theme is the name of the theme to draw with, unknown names use the sport's or the default theme
+	Theme string
+	// AspectRatio is the shape of images, unsupported ratios use the configured one
+	AspectRatio AspectRatio
+}
+
+// AssetParams are the query parameters of a generated asset's path
+type AssetParams struct {
+	Page    int
+	MatchID int
+	Style   Style
+	// Format is what images are encoded as when their filename doesn't have an extension, the configured format is
+	// used when it's empty
+	Format ImageFormat
 }
 
 type Service interface {
-	GetAssetPath(buttonIndex int) string
-	DrawFile(ctx context.Context, filename string) (bytes.Buffer, error)
+	GetRootAssetPath(style Style) string
+	GetSportAssetPath(ctx context.Context, gameType sports.GameType, page int, style Style) string
+	GetMatchAssetPath(ctx context.Context, matchID int, style Style) string
+	// HasTheme is whether images can be drawn with the theme called name
+	HasTheme(name string) bool
+	// GetAspectRatio is the aspect ratio images asked to be drawn in ratio are drawn in
+	GetAspectRatio(ratio AspectRatio) AspectRatio
+	// GetImageFormat is what the image at filename is encoded as, ok is false for extensions that aren't a format
+	GetImageFormat(filename string, params AssetParams) (format ImageFormat, ok bool)
+	// GetAssetVersion is the content address of an image, which changes whenever the data drawn on it does. It's
+	// empty for images that can't be addressed by their content
+	GetAssetVersion(ctx context.Context, filename string, params AssetParams) string
+	// GetPageCount and GetPageMatches page through matches as they're laid out in images of the aspect ratio
+	GetPageCount(ctx context.Context, gameType sports.GameType, ratio AspectRatio) (int, error)
+	GetPageMatches(ctx context.Context, gameType sports.GameType, page int, ratio AspectRatio) ([]sports.Match, error)
+	// DrawFile draws an image along with the version of exactly what was drawn, which can be newer than the one
+	// GetAssetVersion returned a moment before
+	DrawFile(ctx context.Context, filename string, params AssetParams) (buf bytes.Buffer, version string, err error)
+}
+
+// ServiceOptions control the size and encoding of images and how many are kept in memory
+type ServiceOptions struct {
+	// ImageHeight is how tall images are in pixels, their width follows from their aspect ratio
+	ImageHeight int
+	// AspectRatio is the shape of images that don't ask for one
+	AspectRatio AspectRatio
+	// Format is what images are encoded as in the paths handed out for them
+	Format ImageFormat
+	// Budgets are the most bytes an image can take up in each format, images are degraded until they fit. Formats
+	// without a budget aren't limited
+	Budgets map[ImageFormat]int
+	// FrameDelay is how long animations show each page of matches for, and MaxFrames the most pages they show
+	FrameDelay       time.Duration
+	MaxFrames        int
+	RenderCacheBytes int
 }
 
-func NewService(sportsService sports.Service) Service {
+// NewService draws images of sportsService's matches in themes with team images from images
+func NewService(
+	sportsService sports.Service,
+	fonts *FontManager,
+	images *ImageLoader,
+	themes *Themes,
+	options ServiceOptions,
+) (Service, error) {
+	if options.ImageHeight <= 0 {
+		return nil, fmt.Errorf("invalid image height %d", options.ImageHeight)
+	}
+	if _, ok := ParseAspectRatio(string(options.AspectRatio)); !ok {
+		return nil, fmt.Errorf("unsupported aspect ratio %q", options.AspectRatio)
+	}
+	// Frames only show raster images, SVGs are for embedding elsewhere
+	if format, ok := ParseImageFormat(string(options.Format)); !ok || format == ImageFormatSVG {
+		return nil, fmt.Errorf("unsupported image format %q", options.Format)
+	}
+	if options.FrameDelay <= 0 || options.MaxFrames <= 0 {
+		return nil, fmt.Errorf("invalid animation of %d frames every %s", options.MaxFrames, options.FrameDelay)
+	}
+
 	return &service{
 		sportsService: sportsService,
-	}
+		fonts:         fonts,
+		images:        images,
+		themes:        themes,
+		options:       options,
+		renderCache:   newRenderCache(options.RenderCacheBytes),
+	}, nil
 }
 
 type service struct {
 	sportsService sports.Service
+	fonts         *FontManager
+	images        *ImageLoader
+	themes        *Themes
+	options       ServiceOptions
+	renderCache   *renderCache
 }
 
-func (s *service) GetAssetPath(buttonIndex int) string {
-	// Timestamp is used to prevent caching
-	timestamp := time.Now().Unix()
-	return fmt.Sprintf("%s/%d/%s", generatedDirectory, timestamp, assetMapping[buttonIndex])
+func (s *service) GetRootAssetPath(style Style) string {
+	return s.getAssetPath(context.Background(), rootScreen, AssetParams{Style: style})
 }
 
-func (s *service) DrawFile(ctx context.Context, filename string) (bytes.Buffer, error) {
-	switch filename {
-	case "root.png":
-		return s.DrawRoot()
-	case "tennis.png":
-		return s.DrawTennis(ctx)
-	case "basketball.png":
-		return s.DrawBasketball(ctx)
-	default:
-		return bytes.Buffer{}, nil
+func (s *service) GetSportAssetPath(ctx context.Context, gameType sports.GameType, page int, style Style) string {
+	sport, _ := sports.GetSport(gameType)
+	return s.getAssetPath(ctx, sport.Slug, AssetParams{Page: page, Style: style})
+}
+
+func (s *service) GetMatchAssetPath(ctx context.Context, matchID int, style Style) string {
+	return s.getAssetPath(ctx, matchScreen, AssetParams{MatchID: matchID, Style: style})
+}
+
+func (s *service) HasTheme(name string) bool {
+	return s.themes.Has(name)
+}
+
+func (s *service) GetAspectRatio(ratio AspectRatio) AspectRatio {
+	if _, ok := ParseAspectRatio(string(ratio)); ok {
+		return ratio
 	}
+
+	return s.options.AspectRatio
 }
 
-func (s *service) DrawRoot() (bytes.Buffer, error) {
-	imageContext := gg.NewContext(frameImageX, frameImageY)
-	imageContext.SetFontFace(GetFont(assets.FontFiraCode, 72))
+func (s *service) GetImageFormat(filename string, params AssetParams) (ImageFormat, bool) {
+	_, format, ok := s.parseFilename(filename, params)
+	return format, ok
+}
 
-	imageContext.SetRGB255(0, 0, 0)
-	imageContext.Clear()
-	imageContext.SetRGB255(254, 254, 254)
-	imageContext.DrawStringAnchored("Live Sports Scores", frameImageX/2, frameImageY/4, 0.5, 0.5)
-	imageContext.SetFontFace(GetFont(assets.FontNotoEmoji, 72))
+// parseFilename splits the filename of an image into the screen it's of and what it's encoded as
+func (s *service) parseFilename(filename string, params AssetParams) (string, ImageFormat, bool) {
+	extension := path.Ext(filename)
+	screen := strings.TrimSuffix(filename, extension)
+	if extension != "" {
+		format, ok := ParseImageFormat(extension)
+		return screen, format, ok
+	}
+	if format, ok := ParseImageFormat(string(params.Format)); ok {
+		return screen, format, true
+	}
+
+	return screen, s.options.Format, true
+}
 
-	// Most emojis are busted: https://github.com/fogleman/gg/issues/7
-	imageContext.DrawString("⚽⚾⛳⛸️", frameImageX/2.40, frameImageY/2)
+// getGrid lays out images drawn in ratio
+func (s *service) getGrid(ratio AspectRatio) grid {
+	return newGrid(s.GetAspectRatio(ratio).getCanvasSize(s.options.ImageHeight))
+}
 
-	var buf bytes.Buffer
-	err := png.Encode(&buf, imageContext.Image())
+func (s *service) GetAssetVersion(ctx context.Context, filename string, params AssetParams) string {
+	screen, format, ok := s.parseFilename(filename, params)
+	if !ok {
+		return ""
+	}
+	source, err := s.getRenderSource(ctx, screen, format, params)
+	if err != nil {
+		return ""
+	}
 
-	return buf, err
+	return source.version()
 }
 
-func (s *service) DrawBasketball(ctx context.Context) (bytes.Buffer, error) {
-	matches, err := s.sportsService.GetMatches(ctx, sports.Basketball, true)
+func (s *service) getAssetPath(ctx context.Context, screen string, params AssetParams) string {
+	// Paths change along with the data drawn on the image, so clients can cache each one forever
+	filename := screen + s.options.Format.extension()
+	version := s.GetAssetVersion(ctx, filename, params)
+	if version == "" {
+		// Images that can't be addressed by content still need a path that busts caches
+		version = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	assetPath := fmt.Sprintf("%s/%s/%s", generatedDirectory, version, filename)
+
+	query := url.Values{}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.MatchID > 0 {
+		query.Set("id", strconv.Itoa(params.MatchID))
+	}
+	if params.Style.Theme != "" {
+		query.Set("theme", params.Style.Theme)
+	}
+	if params.Style.AspectRatio != "" {
+		query.Set("aspect_ratio", string(params.Style.AspectRatio))
+	}
+	if len(query) > 0 {
+		assetPath = fmt.Sprintf("%s?%s", assetPath, query.Encode())
+	}
+
+	return assetPath
+}
+
+// GetPageCount returns how many pages of matches a sport has, which is always at least one
+func (s *service) GetPageCount(ctx context.Context, gameType sports.GameType, ratio AspectRatio) (int, error) {
+	matches, err := s.sportsService.GetMatches(ctx, gameType, true)
+	if errors.Is(err, sports.ErrMatchesUnavailable) {
+		return 1, nil
+	}
 	if err != nil {
-		return bytes.Buffer{}, err
+		return 1, err
 	}
-	buf, err := s.drawSport(ctx, sports.Basketball, matches)
-	return buf, err
+
+	return pageCount(len(matches), s.getGrid(ratio).perPage()), nil
 }
 
-func (s *service) DrawTennis(ctx context.Context) (bytes.Buffer, error) {
-	matches, err := s.sportsService.GetMatches(ctx, sports.Tennis, true)
+// GetPageMatches returns the matches drawn on a page of a sport, in the order they're numbered in the image
+func (s *service) GetPageMatches(
+	ctx context.Context,
+	gameType sports.GameType,
+	page int,
+	ratio AspectRatio,
+) ([]sports.Match, error) {
+	matches, err := s.sportsService.GetMatches(ctx, gameType, true)
+	if errors.Is(err, sports.ErrMatchesUnavailable) {
+		return nil, nil
+	}
 	if err != nil {
-		return bytes.Buffer{}, err
+		return nil, err
 	}
-	buf, err := s.drawSport(ctx, sports.Tennis, matches)
-	return buf, err
+
+	perPage := s.getGrid(ratio).perPage()
+	page = clampPage(page, pageCount(len(matches), perPage))
+	return lo.Slice(matches, page*perPage, (page+1)*perPage), nil
 }
 
-func (s *service) drawSport(_ context.Context, gameType sports.GameType, matches []sports.Match) (
-	bytes.Buffer,
-	error,
-) {
-	imageContext := gg.NewContext(frameImageX, frameImageY)
-	imageContext.SetRGB255(0, 0, 0)
-	imageContext.Clear()
+// DrawFile draws an image once per version of the matches on it, serving it from the render cache after that
+func (s *service) DrawFile(ctx context.Context, filename string, params AssetParams) (bytes.Buffer, string, error) {
+	screen, format, ok := s.parseFilename(filename, params)
+	if !ok {
+		return bytes.Buffer{}, "", fmt.Errorf("unsupported image format %s", path.Ext(filename))
+	}
 
-	// Set title font and color
-	titleFont := GetFont(assets.FontFiraCode, 72)
-	imageContext.SetFontFace(titleFont)
-	imageContext.SetRGB255(254, 254, 254)
-	imageContext.DrawStringAnchored(fmt.Sprintf("Live %s Scores", gameType), frameImageX/2, frameImageY/12, 0.5, 0.5)
+	source, err := s.getRenderSource(ctx, screen, format, params)
+	if err != nil {
+		return bytes.Buffer{}, "", err
+	}
+	if !source.cacheable {
+		buf, _, err := s.drawFile(ctx, source)
+		return buf, "", err
+	}
 
-	if len(matches) == 0 {
-		subTitleFont := GetFont(assets.FontFiraCode, 50)
-		imageContext.SetFontFace(subTitleFont)
-		imageContext.DrawStringAnchored("No live matches found :(", frameImageX/2, frameImageY/3, 0.5, 0.5)
-
-		var buf bytes.Buffer
-		err := png.Encode(&buf, imageContext.Image())
-
-		return buf, err
-
-	}
-	// Set font for player names and scores
-	playerNameFontSize := float64(60)
-	playerNameFont := GetFont(assets.FontFiraCode, playerNameFontSize)
-	imageContext.SetFontFace(playerNameFont)
-
-	const paddingLeft float64 = 20
-	const paddingRight float64 = 20
-	var startX, startY float64 = paddingLeft, frameImageY / 6
-	const boxHeight float64 = 130
-	const boxWidth float64 = float64(frameImageX) / 2 // Two boxes per row
-	const paddingBetweenBoxes float64 = 10
-
-	// Determine the maximum score width
-	maxScoreWidth := 0.0
-	for _, match := range matches {
-		homeScoresStr := fmt.Sprintf("%v", match.Score.Home)
-		awayScoresStr := fmt.Sprintf("%v", match.Score.Away)
-		homeScoreWidth, _ := imageContext.MeasureString(homeScoresStr)
-		awayScoreWidth, _ := imageContext.MeasureString(awayScoresStr)
-		if homeScoreWidth > maxScoreWidth {
-			maxScoreWidth = homeScoreWidth
+	buf, complete, err := s.renderCache.getOrRender(
+		ctx, source.key, func(ctx context.Context) (bytes.Buffer, bool, error) {
+			return s.drawFile(ctx, source)
+		},
+	)
+	if !complete {
+		// Images drawn with placeholders for images that are still loading aren't what the version addresses
+		return buf, "", err
+	}
+
+	return buf, source.version(), err
+}
+
+// renderSource is everything an image is drawn from. The matches are read from the sports service once, along with
+// the status its key is versioned by, so the image drawn is always the one the key addresses
+type renderSource struct {
+	screen string
+	format ImageFormat
+	page   int
+	theme  Theme
+	layout grid
+	// sport is nil for screens that aren't of a sport, matches and availability are what's drawn of it
+	sport        *sports.Sport
+	matches      []sports.Match
+	availability matchesAvailability
+	// match is the match drawn on the match screen, nil when it's no longer available
+	match *sports.Match
+	key   renderKey
+	// cacheable is false for images that can't be addressed by their content
+	cacheable bool
+}
+
+// version is the content address of the image, it's empty for images that can't be addressed by their content
+func (r renderSource) version() string {
+	if !r.cacheable {
+		return ""
+	}
+
+	return r.key.hash()
+}
+
+// getRenderSource reads what an image of screen is drawn from and works out the key its render is cached by
+func (s *service) getRenderSource(
+	ctx context.Context,
+	screen string,
+	format ImageFormat,
+	params AssetParams,
+) (renderSource, error) {
+	source := renderSource{
+		screen: screen,
+		format: format,
+		page:   params.Page,
+		layout: s.getGrid(params.Style.AspectRatio),
+	}
+	source.key = renderKey{
+		canvas: fmt.Sprintf("%gx%g", source.layout.width, source.layout.height),
+		format: fmt.Sprintf("%s-%d", format, s.options.Budgets[format]),
+	}
+	if format == ImageFormatGIF {
+		source.key.format += fmt.Sprintf("-%s-%d", s.options.FrameDelay, s.options.MaxFrames)
+	}
+
+	switch screen {
+	case rootScreen:
+		source.theme = s.themes.get(params.Style.Theme, "")
+		source.key.screen, source.cacheable = rootScreen, true
+	case matchScreen:
+		source.key.screen, source.key.matchID = matchScreen, params.MatchID
+		match, status, err := s.sportsService.GetMatchWithStatus(ctx, params.MatchID)
+		switch {
+		case errors.Is(err, sports.ErrMatchNotFound):
+			// Missing matches are drawn as a message that only depends on the id
+			source.theme = s.themes.get(params.Style.Theme, "")
+		case err != nil:
+			return renderSource{}, err
+		default:
+			sport, _ := sports.GetSport(match.GameType)
+			source.theme = s.themes.get(params.Style.Theme, sport.Slug)
+			source.match = &match
+			// The match clock moves on every minute even when the score doesn't
+			source.key.version = fmt.Sprintf("%s-%d", status.Version, time.Now().Unix()/60)
 		}
-		if awayScoreWidth > maxScoreWidth {
-			maxScoreWidth = awayScoreWidth
+		source.cacheable = true
+	default:
+		source.theme = s.themes.get(params.Style.Theme, screen)
+		sport, ok := sports.GetSportBySlug(screen)
+		if !ok {
+			break
 		}
-	}
 
-	for i, match := range matches {
-		if i%2 == 0 && i != 0 { // Move to next row after every 2 matches
-			startY += boxHeight + paddingBetweenBoxes
+		source.sport = &sport
+		source.key.screen, source.key.page = sport.Slug, params.Page
+		if format == ImageFormatGIF {
+			// Animations show every page whichever one they're asked for
+			source.key.page = 0
+		}
+		matches, status, err := s.sportsService.GetMatchesWithStatus(ctx, sport.GameType, true)
+		switch {
+		case errors.Is(err, sports.ErrMatchesUnavailable):
+			source.availability = matchesUnavailable
+			source.key.version = "unavailable"
+		case err != nil:
+			return renderSource{}, err
+		case status.Stale:
+			source.matches, source.availability = matches, matchesStale
+			source.key.version = status.Version + "-stale"
+		default:
+			source.matches, source.availability = matches, matchesAvailable
+			source.key.version = status.Version
 		}
+		source.cacheable = true
+	}
+	source.key.theme = source.theme.hash
 
-		// Draw rectangle for the current match
-		imageContext.DrawRectangle(startX, startY, boxWidth-paddingRight, boxHeight)
-		imageContext.SetRGB255(255, 255, 255) // Set color to white for filling
-		imageContext.FillPreserve()           // Fill the rectangle and preserve the path for stroking
-		imageContext.SetRGB255(0, 0, 0)       // Set color to black for the border
-		imageContext.SetLineWidth(2)          // Set the line width for the border
-		imageContext.Stroke()                 // Stroke the border
+	return source, nil
+}
 
-		// Set text color to black for drawing names and scores
-		imageContext.SetRGB255(0, 0, 0)
+// drawFile draws and encodes the image of source, complete is false when images on it were left out because they took
+// too long to load
+func (s *service) drawFile(ctx context.Context, source renderSource) (buf bytes.Buffer, complete bool, err error) {
+	if source.format == ImageFormatGIF {
+		return s.drawAnimation(ctx, source)
+	}
+
+	sc, err := s.drawScreen(ctx, source)
+	if err != nil || sc == nil {
+		return bytes.Buffer{}, false, err
+	}
 
-		// Calculate vertical center for the text
-		textYHome := startY + boxHeight/4 + playerNameFontSize/3
-		textYAway := startY + 3*boxHeight/4 + playerNameFontSize/3
+	if source.format == ImageFormatSVG {
+		buf, err = s.fonts.encodeSVG(sc)
+	} else {
+		buf, err = encodeImage(s.fonts.drawScene(sc), source.format, s.options.Budgets[source.format])
+	}
 
-		// Draw names on the left side
-		imageContext.DrawString(match.Home.Name, startX, textYHome)
-		imageContext.DrawString(match.Away.Name, startX, textYAway)
+	return buf, !sc.partial, err
+}
 
-		// Draw scores on the right side, aligned based on the maximum score width
-		homeScoresStr := fmt.Sprintf("%s", reduceScore(match.Score.Home))
-		awayScoresStr := fmt.Sprintf("%s", reduceScore(match.Score.Away))
+// drawScreen lays out the image of a screen, which is nil for sports that don't exist
+func (s *service) drawScreen(ctx context.Context, source renderSource) (*scene, error) {
+	switch {
+	case source.screen == rootScreen:
+		return s.DrawRoot(source.theme, source.layout)
+	case source.screen == matchScreen:
+		return s.DrawMatch(source.theme, source.layout, source.match)
+	case source.sport == nil:
+		return nil, nil
+	}
 
-		// Position scores on the right by using the maximum score width
-		imageContext.DrawString(homeScoresStr, startX+boxWidth-paddingRight-maxScoreWidth, textYHome)
-		imageContext.DrawString(awayScoresStr, startX+boxWidth-paddingRight-maxScoreWidth, textYAway)
+	return s.drawSport(
+		ctx,
+		source.theme,
+		source.layout,
+		*source.sport,
+		source.matches,
+		source.page,
+		source.availability,
+	)
+}
 
-		startX += boxWidth // Move to the next column
-		if i%2 == 1 {      // At the end of the row, reset startX for the next row
-			startX = paddingLeft
+// drawAnimation draws a sport as an animation of its pages of matches, other screens are a single frame
+func (s *service) drawAnimation(ctx context.Context, source renderSource) (bytes.Buffer, bool, error) {
+	var scenes []*scene
+	if source.sport != nil {
+		pages, err := s.DrawSportPages(
+			ctx,
+			source.theme,
+			source.layout,
+			*source.sport,
+			source.matches,
+			source.availability,
+			s.options.MaxFrames,
+		)
+		if err != nil {
+			return bytes.Buffer{}, false, err
 		}
+		scenes = pages
+	} else {
+		sc, err := s.drawScreen(ctx, source)
+		if err != nil || sc == nil {
+			return bytes.Buffer{}, false, err
+		}
+		scenes = []*scene{sc}
 	}
 
-	var buf bytes.Buffer
-	err := png.Encode(&buf, imageContext.Image())
+	frames := lo.Map(
+		scenes, func(sc *scene, _ int) image.Image {
+			return s.fonts.drawScene(sc)
+		},
+	)
+	complete := !lo.SomeBy(
+		scenes, func(sc *scene) bool {
+			return sc.partial
+		},
+	)
+	buf, err := encodeAnimation(frames, s.options.FrameDelay, s.options.Budgets[ImageFormatGIF])
 
-	return buf, err
+	return buf, complete, err
 }
 
-func reduceScore(score []string) string {
-	return lo.Reduce(
-		score,
-		func(acc string, curr string, _ int) string {
-			return fmt.Sprintf("%s %s", acc, curr)
-		},
-		"",
+func (s *service) DrawRoot(theme Theme, layout grid) (*scene, error) {
+	faces := s.fonts.newFaces()
+	defer faces.release()
+
+	sc := newScene(layout.width, layout.height, theme.Colors.Background)
+	title := "Live Sports Scores"
+	faces.drawString(
+		sc,
+		theme.Fonts.Regular,
+		faces.fitSize(theme.Fonts.Regular, layout.scale(theme.Fonts.Title), title, layout.textWidth()),
+		theme.Colors.Text,
+		title,
+		layout.width/2,
+		layout.height/4,
+		0.5,
+		0.5,
+	)
+
+	// A row of every sport there are scores for
+	emojis := strings.Join(
+		lo.Map(
+			sports.Sports(), func(sport sports.Sport, _ int) string {
+				return sport.Emoji
+			},
+		),
+		" ",
+	)
+	faces.drawString(
+		sc,
+		theme.Fonts.Regular,
+		faces.fitSize(theme.Fonts.Regular, layout.scale(rootEmojiFontSize), emojis, layout.textWidth()),
+		theme.Colors.Text,
+		emojis,
+		layout.width/2,
+		layout.height/2,
+		0.5,
+		0.5,
+	)
+
+	return sc, nil
+}
+
+// DrawSportPages draws each page of a sport's matches, up to maxPages of them
+func (s *service) DrawSportPages(
+	ctx context.Context,
+	theme Theme,
+	layout grid,
+	sport sports.Sport,
+	matches []sports.Match,
+	availability matchesAvailability,
+	maxPages int,
+) ([]*scene, error) {
+	pages := min(pageCount(len(matches), layout.perPage()), maxPages)
+	scenes := make([]*scene, 0, pages)
+	for page := 0; page < pages; page++ {
+		sc, err := s.drawSport(ctx, theme, layout, sport, matches, page, availability)
+		if err != nil {
+			return nil, err
+		}
+		scenes = append(scenes, sc)
+	}
+
+	return scenes, nil
+}
+
+func (s *service) drawSport(
+	ctx context.Context,
+	theme Theme,
+	layout grid,
+	sport sports.Sport,
+	matches []sports.Match,
+	page int,
+	availability matchesAvailability,
+) (
+	*scene,
+	error,
+) {
+	faces := s.fonts.newFaces()
+	defer faces.release()
+
+	sc := newScene(layout.width, layout.height, theme.Colors.Background)
+	title := fmt.Sprintf("%s Live %s Scores", sport.Emoji, sport.DisplayName)
+	faces.drawString(
+		sc,
+		theme.Fonts.Regular,
+		faces.fitSize(theme.Fonts.Regular, layout.scale(theme.Fonts.Title), title, layout.textWidth()),
+		theme.Colors.Text,
+		title,
+		layout.width/2,
+		layout.scale(headerHeight)/2,
+		0.5,
+		0.5,
 	)
+
+	if len(matches) == 0 {
+		message := "No live matches found :("
+		if availability == matchesUnavailable {
+			message = "Scores are temporarily unavailable"
+		}
+
+		subtitleSize := layout.scale(theme.Fonts.Subtitle)
+		faces.drawString(
+			sc,
+			theme.Fonts.Regular,
+			faces.fitSize(theme.Fonts.Regular, subtitleSize, message, layout.textWidth()),
+			theme.Colors.Text,
+			message,
+			layout.width/2,
+			layout.height/3,
+			0.5,
+			0.5,
+		)
+
+		return sc, nil
+	}
+
+	perPage := layout.perPage()
+	pages := pageCount(len(matches), perPage)
+	page = clampPage(page, pages)
+	matches = lo.Slice(matches, page*perPage, (page+1)*perPage)
+	teamImages, complete := s.loadTeamImages(ctx, matches)
+	sc.partial = !complete
+
+	// Footer text is placed by its baseline so it isn't pushed off the bottom of the image. The page sits on the
+	// right so it never runs into the stale note on narrow images
+	margin := layout.scale(theme.Spacing.Margin)
+	footerSize := layout.scale(theme.Fonts.Footer)
+	footerBaselineY := layout.height - layout.scale(footerHeight)/3
+	if availability == matchesStale {
+		faces.drawString(
+			sc,
+			theme.Fonts.Regular,
+			footerSize,
+			theme.Colors.Text,
+			"Scores may be out of date",
+			margin,
+			footerBaselineY,
+			0,
+			0,
+		)
+	}
+	if pages > 1 {
+		faces.drawString(
+			sc,
+			theme.Fonts.Regular,
+			footerSize,
+			theme.Colors.Text,
+			fmt.Sprintf("Page %d/%d", page+1, pages),
+			layout.width-margin,
+			footerBaselineY,
+			1,
+			0,
+		)
+	}
+
+	// Everything in a card is scaled with it
+	matchNumberSize := layout.scaleCard(matchNumberFontSize)
+	nameSize := layout.scaleCard(theme.Fonts.Name)
+	columnPadding := layout.scaleCard(scoreColumnPadding)
+	fonts := newScoreFonts(faces, theme, nameSize, layout.scaleCard(theme.Fonts.Label), columnPadding)
+	cardHeight := layout.cardHeight()
+	labelHeight := layout.scaleCard(periodLabelHeight)
+	numberWidth := layout.scaleCard(matchNumberWidth)
+	imageSize := layout.scaleCard(teamImageSize)
+	serveRadius := layout.scaleCard(serveIndicatorRadius)
+
+	for i, match := range matches {
+		startX, startY, cardWidth := layout.getCard(i, theme.Spacing.Margin, theme.Spacing.Gap)
+		drawCard(sc, theme, startX, startY, cardWidth, cardHeight)
+
+		// Number matches so they can be picked for the detail screen
+		faces.drawString(
+			sc,
+			theme.Fonts.Regular,
+			matchNumberSize,
+			theme.Colors.Muted,
+			strconv.Itoa(i+1),
+			startX+numberWidth/2,
+			startY+cardHeight/2,
+			0.5,
+			0.5,
+		)
+
+		// Period labels run along the top of the card, with a row per team under them
+		// gg anchors text by its full line height, so rows are placed by their baselines instead
+		rowHeight := (cardHeight - labelHeight) / 2
+		rowYHome := startY + labelHeight
+		labelY := startY + labelHeight*0.8
+		textYHome := rowYHome + rowHeight*0.75
+		textYAway := textYHome + rowHeight
+
+		if theme.Card.HighlightWinner {
+			drawLeaderHighlight(
+				sc,
+				theme,
+				match.Score,
+				startX,
+				startY,
+				cardWidth,
+				cardHeight,
+				rowYHome,
+				rowHeight,
+			)
+		}
+
+		// Draw a column per period on the right side, ending with the totals. Long matches like extra innings only
+		// show their latest periods, so the names keep minTeamNameWidth
+		imageX := startX + numberWidth
+		nameX := imageX + imageSize + layout.scaleCard(teamImagePadding)
+		columnsEndX := startX + cardWidth - layout.scaleCard(cardPadding)
+		columns := fitScoreColumns(
+			fonts,
+			getScoreColumns(match.Score),
+			columnsEndX-nameX-layout.scaleCard(minTeamNameWidth)-2*serveRadius-columnPadding,
+		)
+		columnsStartX := columnsEndX - getScoreColumnsWidth(fonts, columns)
+
+		// Draw each team's image and name on the left side, in whatever room the scores and serve indicator leave
+		drawTeamImage(sc, faces, theme, match.Home, teamImages, imageX, rowYHome, rowHeight, imageSize)
+		drawTeamImage(
+			sc,
+			faces,
+			theme,
+			match.Away,
+			teamImages,
+			imageX,
+			rowYHome+rowHeight,
+			rowHeight,
+			imageSize,
+		)
+		nameWidth := columnsStartX - nameX - 2*serveRadius - columnPadding
+		faces.drawTeamNames(
+			sc,
+			theme.Fonts.Regular,
+			nameSize,
+			theme.Colors.CardText,
+			match,
+			nameX,
+			textYHome,
+			textYAway,
+			nameWidth,
+		)
+		faces.drawScoreLabels(sc, fonts, theme.Colors.CardText, columns, columnsStartX, labelY)
+		faces.drawScoreColumns(sc, fonts, theme.Colors.CardText, columns, columnsStartX, textYHome, textYAway)
+
+		if match.Score.Tennis != nil && match.Score.Tennis.Server != sports.NoSide {
+			serverY := textYHome
+			if match.Score.Tennis.Server == sports.AwaySide {
+				serverY = textYAway
+			}
+			drawServeIndicator(sc, theme, columnsStartX-serveRadius, serverY-nameSize/3, serveRadius)
+		}
+	}
+
+	return sc, nil
+}
+
+// hasTotals is false for sports where the provider doesn't report a running total
+func hasTotals(score sports.Score) bool {
+	return score.HomeTotal != "" || score.AwayTotal != ""
+}
+
+// getPeriodScore returns an empty score for periods one side has no score for
+func getPeriodScore(scores []string, period int) string {
+	if period >= len(scores) {
+		return ""
+	}
+
+	return scores[period]
+}
+
+func pageCount(matchCount int, perPage int) int {
+	if matchCount == 0 {
+		return 1
+	}
+
+	return (matchCount + perPage - 1) / perPage
+}
+
+func clampPage(page int, pages int) int {
+	if page < 0 {
+		return 0
+	}
+	if page >= pages {
+		return pages - 1
+	}
+
+	return page
 }
@@ -0,0 +1,292 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goki/freetype/truetype"
+	"github.com/samber/lo"
+)
+
+// svgImageDensity is how many pixels of an image are kept per pixel it's drawn at, so images stay sharp when SVGs are
+// shown bigger than their size without embedding images far bigger than they're drawn
+const svgImageDensity = 2
+
+// svgWriter writes a scene out as an SVG document. Fonts and images are embedded rather than linked, so the document
+// looks the same wherever it's shown, including in img tags that can't load anything else. Fonts are cut down to the
+// glyphs the scene draws, whole fonts would take up most of the document
+type svgWriter struct {
+	buf    bytes.Buffer
+	fonts  *FontManager
+	clips  map[*clipShape]string
+	images map[image.Image]svgImage
+}
+
+// svgImage is an image embedded in the document's defs
+type svgImage struct {
+	id     string
+	width  int
+	height int
+}
+
+// encodeSVG writes sc as an SVG document with one shape per line, so changes to layouts can be diffed as text
+func (m *FontManager) encodeSVG(sc *scene) (bytes.Buffer, error) {
+	w := &svgWriter{
+		fonts:  m,
+		clips:  make(map[*clipShape]string),
+		images: make(map[image.Image]svgImage),
+	}
+
+	width, height := formatSVGNumber(sc.width), formatSVGNumber(sc.height)
+	fmt.Fprintf(
+		&w.buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" xml:space="preserve">`+"\n",
+		width,
+		height,
+		width,
+		height,
+	)
+	if err := w.writeDefs(sc); err != nil {
+		return bytes.Buffer{}, err
+	}
+	fmt.Fprintf(&w.buf, `<rect width="100%%" height="100%%"%s/>`+"\n", getSVGPaint("fill", sc.background))
+
+	var clip *clipShape
+	for _, item := range sc.items {
+		if item.clip != clip {
+			if clip != nil {
+				w.buf.WriteString("</g>\n")
+			}
+			if item.clip != nil {
+				fmt.Fprintf(&w.buf, `<g clip-path="url(#%s)">`+"\n", w.clips[item.clip])
+			}
+			clip = item.clip
+		}
+
+		w.writeShape(item.shape)
+	}
+	if clip != nil {
+		w.buf.WriteString("</g>\n")
+	}
+	w.buf.WriteString("</svg>\n")
+
+	return w.buf, nil
+}
+
+// writeDefs embeds the fonts, clips and images the scene uses, each once however many times it's used
+func (w *svgWriter) writeDefs(sc *scene) error {
+	glyphs := make(map[string][]truetype.Index)
+	imageSizes := make(map[image.Image]float64)
+	for _, item := range sc.items {
+		switch shape := item.shape.(type) {
+		case textShape:
+			// Characters a font doesn't have are drawn with its fallbacks, like they are in images
+			chain := getFontChain(shape.fontType)
+			glyphs[shape.fontType] = append(glyphs[shape.fontType], 0)
+			for _, r := range shape.text {
+				fontType, glyph := w.fonts.getFontFor(chain, r)
+				glyphs[fontType] = append(glyphs[fontType], glyph)
+			}
+		case imageShape:
+			imageSizes[shape.img] = max(imageSizes[shape.img], shape.width, shape.height)
+		}
+	}
+
+	w.buf.WriteString("<defs>\n")
+
+	// Fonts are sorted so the same scene is always written the same way
+	fontTypes := lo.Keys(glyphs)
+	sort.Strings(fontTypes)
+	for _, fontType := range fontTypes {
+		subset, err := subsetFont(w.fonts.data[fontType], glyphs[fontType])
+		if err != nil {
+			return fmt.Errorf("unable to subset font %s for svg: %w", fontType, err)
+		}
+		fmt.Fprintf(
+			&w.buf,
+			`<style>@font-face{font-family:"%s";src:url(data:font/ttf;base64,%s)}</style>`+"\n",
+			getSVGFontFamily(fontType),
+			base64.StdEncoding.EncodeToString(subset),
+		)
+	}
+
+	for _, item := range sc.items {
+		if _, ok := w.clips[item.clip]; item.clip != nil && !ok {
+			id := fmt.Sprintf("clip%d", len(w.clips))
+			w.clips[item.clip] = id
+			fmt.Fprintf(&w.buf, `<clipPath id="%s">`, id)
+			w.writeRect(rectShape{
+				x:      item.clip.x,
+				y:      item.clip.y,
+				width:  item.clip.width,
+				height: item.clip.height,
+				radius: item.clip.radius,
+				fill:   color.Black,
+			}, "")
+			w.buf.WriteString("</clipPath>\n")
+		}
+
+		shape, ok := item.shape.(imageShape)
+		if _, embedded := w.images[shape.img]; !ok || embedded {
+			continue
+		}
+		if err := w.writeImageDef(shape.img, imageSizes[shape.img]); err != nil {
+			return err
+		}
+	}
+
+	w.buf.WriteString("</defs>\n")
+	return nil
+}
+
+func (w *svgWriter) writeImageDef(img image.Image, drawnSize float64) error {
+	key := img
+	if maxSize := int(math.Ceil(drawnSize * svgImageDensity)); max(img.Bounds().Dx(), img.Bounds().Dy()) > maxSize {
+		img = scaleToFit(img, maxSize)
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return fmt.Errorf("unable to embed image in svg: %w", err)
+	}
+
+	embedded := svgImage{
+		id:     fmt.Sprintf("image%d", len(w.images)),
+		width:  img.Bounds().Dx(),
+		height: img.Bounds().Dy(),
+	}
+	w.images[key] = embedded
+	fmt.Fprintf(
+		&w.buf,
+		`<image id="%s" width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+		embedded.id,
+		embedded.width,
+		embedded.height,
+		base64.StdEncoding.EncodeToString(encoded.Bytes()),
+	)
+
+	return nil
+}
+
+func (w *svgWriter) writeShape(shape shape) {
+	switch shape := shape.(type) {
+	case rectShape:
+		w.writeRect(shape, "\n")
+	case circleShape:
+		fmt.Fprintf(
+			&w.buf,
+			`<circle cx="%s" cy="%s" r="%s"%s%s/>`+"\n",
+			formatSVGNumber(shape.x),
+			formatSVGNumber(shape.y),
+			formatSVGNumber(shape.radius),
+			getSVGPaint("fill", shape.fill),
+			getSVGStroke(shape.stroke, shape.strokeWidth),
+		)
+	case lineShape:
+		fmt.Fprintf(
+			&w.buf,
+			`<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n",
+			formatSVGNumber(shape.x1),
+			formatSVGNumber(shape.y1),
+			formatSVGNumber(shape.x2),
+			formatSVGNumber(shape.y2),
+			getSVGStroke(shape.stroke, shape.strokeWidth),
+		)
+	case textShape:
+		fmt.Fprintf(
+			&w.buf,
+			`<text x="%s" y="%s" font-family="%s" font-size="%s"%s>`,
+			formatSVGNumber(shape.x),
+			formatSVGNumber(shape.y),
+			getSVGFontFamilies(shape.fontType),
+			formatSVGNumber(shape.size),
+			getSVGPaint("fill", shape.fill),
+		)
+		// Writing to a buffer can't fail
+		_ = xml.EscapeText(&w.buf, []byte(shape.text))
+		w.buf.WriteString("</text>\n")
+	case imageShape:
+		embedded := w.images[shape.img]
+		fmt.Fprintf(
+			&w.buf,
+			`<use href="#%s" transform="translate(%s %s) scale(%s %s)"/>`+"\n",
+			embedded.id,
+			formatSVGNumber(shape.x),
+			formatSVGNumber(shape.y),
+			formatSVGNumber(shape.width/float64(embedded.width)),
+			formatSVGNumber(shape.height/float64(embedded.height)),
+		)
+	}
+}
+
+func (w *svgWriter) writeRect(shape rectShape, end string) {
+	radius := ""
+	if shape.radius > 0 {
+		radius = fmt.Sprintf(` rx="%s"`, formatSVGNumber(shape.radius))
+	}
+	fmt.Fprintf(
+		&w.buf,
+		`<rect x="%s" y="%s" width="%s" height="%s"%s%s%s/>%s`,
+		formatSVGNumber(shape.x),
+		formatSVGNumber(shape.y),
+		formatSVGNumber(shape.width),
+		formatSVGNumber(shape.height),
+		radius,
+		getSVGPaint("fill", shape.fill),
+		getSVGStroke(shape.stroke, shape.strokeWidth),
+		end,
+	)
+}
+
+// getSVGPaint is the attributes painting with c, which is nothing at all when c is nil
+func getSVGPaint(attribute string, c color.Color) string {
+	if c == nil {
+		return fmt.Sprintf(` %s="none"`, attribute)
+	}
+
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	paint := fmt.Sprintf(` %s="#%02x%02x%02x"`, attribute, nrgba.R, nrgba.G, nrgba.B)
+	if nrgba.A != 0xff {
+		paint += fmt.Sprintf(` %s-opacity="%s"`, attribute, formatSVGNumber(float64(nrgba.A)/0xff))
+	}
+
+	return paint
+}
+
+func getSVGStroke(stroke color.Color, width float64) string {
+	if stroke == nil || width <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`%s stroke-width="%s"`, getSVGPaint("stroke", stroke), formatSVGNumber(width))
+}
+
+// getSVGFontFamily names an embedded font after its file
+func getSVGFontFamily(fontType string) string {
+	return fontType[:len(fontType)-len(path.Ext(fontType))]
+}
+
+// getSVGFontFamilies lists fontType's family followed by its fallbacks' for text drawn with it
+func getSVGFontFamilies(fontType string) string {
+	families := lo.Map(
+		getFontChain(fontType), func(fontType string, _ int) string {
+			return getSVGFontFamily(fontType)
+		},
+	)
+	return strings.Join(families, ", ")
+}
+
+// formatSVGNumber rounds to hundredths of a pixel, which is finer than anything can be seen but keeps documents short
+func formatSVGNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,135 @@
+package drawing
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/freetype/truetype"
+	"github.com/welps/go-frames-scores/assets"
+	"golang.org/x/image/math/fixed"
+)
+
+var updateGoldens = flag.Bool("update", false, "rewrite golden files in testdata with what's drawn")
+
+// TestEncodeSVG compares SVGs with the ones in testdata, run with -update to rewrite them after changing how scenes
+// are drawn
+func TestEncodeSVG(t *testing.T) {
+	s := newTestService(t)
+	theme := s.themes.get("", "")
+	layout := s.getGrid(AspectRatioWide)
+
+	tests := []struct {
+		name   string
+		golden string
+		draw   func() (*scene, error)
+	}{
+		{
+			name:   "scoreboard",
+			golden: "scoreboard.svg",
+			draw: func() (*scene, error) {
+				return drawTestScoreboard(t, s), nil
+			},
+		},
+		{
+			name:   "match",
+			golden: "match.svg",
+			draw: func() (*scene, error) {
+				return s.drawMatch(theme, layout, getTestMatches(1)[0])
+			},
+		},
+		{
+			name:   "text drawn with a fallback font",
+			golden: "message.svg",
+			draw: func() (*scene, error) {
+				return s.drawMessage(theme, layout, "Full time ⚽︎")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				sc, err := test.draw()
+				if err != nil {
+					t.Fatal(err)
+				}
+				buf, err := s.fonts.encodeSVG(sc)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("testdata", test.golden)
+				if *updateGoldens {
+					if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("encodeSVG() doesn't match %s, run with -update if the change is intended", golden)
+				}
+			},
+		)
+	}
+}
+
+func TestSubsetFont(t *testing.T) {
+	fonts := newTestFontManager(t)
+	data := fonts.data[assets.FontFiraCode]
+	full := fonts.fonts[assets.FontFiraCode]
+
+	var glyphs []truetype.Index
+	for _, r := range "MUN 0123456789…" {
+		glyphs = append(glyphs, full.Index(r))
+	}
+	subset, err := subsetFont(data, glyphs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subset) > len(data)/4 {
+		t.Errorf("subset is %d bytes of the font's %d", len(subset), len(data))
+	}
+
+	parsed, err := truetype.Parse(subset)
+	if err != nil {
+		t.Fatalf("subset doesn't parse: %v", err)
+	}
+	scale := fixed.Int26_6(parsed.FUnitsPerEm())
+	for _, test := range []struct {
+		r    rune
+		kept bool
+	}{
+		{r: 'M', kept: true},
+		{r: '7', kept: true},
+		{r: '…', kept: true},
+		{r: 'z'},
+	} {
+		t.Run(
+			string(test.r), func(t *testing.T) {
+				glyph := parsed.Index(test.r)
+				if glyph != full.Index(test.r) {
+					t.Fatalf("Index(%q) = %d, want %d", test.r, glyph, full.Index(test.r))
+				}
+
+				var want, got truetype.GlyphBuf
+				if err := want.Load(full, scale, glyph, 0); err != nil {
+					t.Fatal(err)
+				}
+				if err := got.Load(parsed, scale, glyph, 0); err != nil {
+					t.Fatal(err)
+				}
+				if kept := len(got.Points) > 0; kept != test.kept {
+					t.Fatalf("%q has %d points, want kept %t", test.r, len(got.Points), test.kept)
+				}
+				if test.kept && (len(got.Points) != len(want.Points) || got.AdvanceWidth != want.AdvanceWidth) {
+					t.Errorf("%q was changed by subsetting", test.r)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,214 @@
+package drawing
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	teamImageSize    float64 = 40
+	teamImagePadding float64 = 12
+	// teamImageTimeout bounds how long a render waits on images, ones that take longer are drawn as placeholders
+	teamImageTimeout   = 3 * time.Second
+	regionalIndicatorA = 0x1F1E6
+	// subdivisionFlagBase and subdivisionFlagCancel spell out flags of countries within countries, like England
+	subdivisionFlagBase   = 0x1F3F4
+	subdivisionFlagCancel = 0xE007F
+)
+
+// subdivisionCodes are the providers' codes for countries that play apart from the rest of their state
+var subdivisionCodes = map[string]string{
+	"ENG": "gbeng",
+	"SCO": "gbsct",
+	"WAL": "gbwls",
+}
+
+// getTeamImageURL is the image drawn next to a team's name, its logo and otherwise its country's flag
+func getTeamImageURL(team sports.Team) string {
+	if team.Logo != "" {
+		return team.Logo
+	}
+
+	return team.Flag
+}
+
+// GetTeamImageMaxSize is the largest team images are drawn at in images imageHeight pixels tall, counting the extra
+// pixels SVGs keep so images stay sharp
+func GetTeamImageMaxSize(imageHeight int) int {
+	return int(math.Ceil(teamImageSize * float64(imageHeight) / baseCanvasSize * svgImageDensity))
+}
+
+// loadTeamImages loads the images of every team in matches at once, teams whose image couldn't be loaded are left out.
+// complete is false when some were left out only because loading them timed out or was cancelled
+func (s *service) loadTeamImages(
+	ctx context.Context,
+	matches []sports.Match,
+) (images map[string]image.Image, complete bool) {
+	ctx, cancel := context.WithTimeout(ctx, teamImageTimeout)
+	defer cancel()
+
+	images = make(map[string]image.Image)
+	complete = true
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	requested := make(map[string]bool)
+	for _, match := range matches {
+		for _, team := range []sports.Team{match.Home, match.Away} {
+			imageURL := getTeamImageURL(team)
+			if imageURL == "" || requested[imageURL] {
+				continue
+			}
+			requested[imageURL] = true
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				img, err := s.images.Load(ctx, imageURL)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err != nil {
+					zap.S().Debugw("Drawing placeholder for team image", "url", imageURL, zap.Error(err))
+					// Images that failed to load on their own are drawn as placeholders until they're retried, but
+					// ones that ran out of time may well load for the next render. That's told from the error rather
+					// than ctx, as waiting on another render's load gets its timeout
+					if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+						complete = false
+					}
+					return
+				}
+				images[imageURL] = img
+			}()
+		}
+	}
+	wg.Wait()
+
+	return images, complete
+}
+
+// drawTeamImage draws a team's logo or flag at the start of its row, scaled to fit and clipped to the row. Teams
+// without either get their country's flag emoji or, failing that, a placeholder with their initial
+func drawTeamImage(
+	sc *scene,
+	faces *faces,
+	theme Theme,
+	team sports.Team,
+	images map[string]image.Image,
+	x, rowY, rowHeight, size float64,
+) {
+	size = min(size, rowHeight)
+	y := rowY + (rowHeight-size)/2
+
+	img, ok := images[getTeamImageURL(team)]
+	if !ok {
+		img, ok = getFlagEmoji(faces, team.CountryCode)
+	}
+	if !ok {
+		drawTeamImagePlaceholder(sc, faces, theme, team, x, y, size)
+		return
+	}
+
+	width, height := getFittedSize(img, int(size))
+	sc.withClip(
+		clipShape{x: x, y: rowY, width: size, height: rowHeight}, func() {
+			sc.add(
+				imageShape{
+					img:    img,
+					x:      x + (size-float64(width))/2,
+					y:      y + (size-float64(height))/2,
+					width:  float64(width),
+					height: float64(height),
+				},
+			)
+		},
+	)
+}
+
+// getFlagEmoji is the emoji of a country's flag, spelled out with regional indicators or subdivision tags
+func getFlagEmoji(faces *faces, countryCode string) (image.Image, bool) {
+	countryCode = strings.ToUpper(countryCode)
+
+	var sequence []rune
+	if subdivision, ok := subdivisionCodes[countryCode]; ok {
+		sequence = append(sequence, subdivisionFlagBase)
+		for _, r := range subdivision {
+			sequence = append(sequence, 0xE0000+r)
+		}
+		sequence = append(sequence, subdivisionFlagCancel)
+	} else {
+		if len(countryCode) != 2 {
+			return nil, false
+		}
+		for _, r := range countryCode {
+			if r < 'A' || r > 'Z' {
+				return nil, false
+			}
+			sequence = append(sequence, regionalIndicatorA+r-'A')
+		}
+	}
+
+	name, ok := faces.manager.emoji.lookup(sequence)
+	if !ok {
+		return nil, false
+	}
+
+	img, err := faces.manager.emoji.getImage(name)
+	if err != nil {
+		zap.S().Errorw("Unable to draw flag", "country", countryCode, zap.Error(err))
+		return nil, false
+	}
+
+	return img, true
+}
+
+func drawTeamImagePlaceholder(sc *scene, faces *faces, theme Theme, team sports.Team, x, y, size float64) {
+	sc.add(circleShape{x: x + size/2, y: y + size/2, radius: size / 2, fill: withAlpha(theme.Colors.Muted, 0.3)})
+
+	initial := []rune(strings.TrimSpace(team.Name))
+	if len(initial) > 0 && unicode.IsLetter(initial[0]) {
+		fontSize := size * 0.6
+		faces.drawString(
+			sc,
+			theme.Fonts.Bold,
+			fontSize,
+			theme.Colors.Muted,
+			strings.ToUpper(string(initial[0])),
+			x+size/2,
+			y+size/2+fontSize*0.35,
+			0.5,
+			0,
+		)
+	}
+}
+
+// getFittedSize is the size img is drawn at to fit in a size square, keeping its aspect ratio
+func getFittedSize(img image.Image, size int) (int, int) {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, size*bounds.Dy()/bounds.Dx())
+	} else {
+		width = max(1, size*bounds.Dx()/bounds.Dy())
+	}
+
+	return width, height
+}
+
+// scaleToFit shrinks or grows img to fit in a size square, keeping its aspect ratio
+func scaleToFit(img image.Image, size int) image.Image {
+	width, height := getFittedSize(img, size)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), xdraw.Over, nil)
+
+	return scaled
+}
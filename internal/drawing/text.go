@@ -0,0 +1,84 @@
+package drawing
+
+import (
+	"image/color"
+	"math"
+
+	"go.uber.org/zap"
+	"golang.org/x/image/font"
+)
+
+// Emoji are drawn as square images sized to the font, sitting on the baseline like a capital letter with a descender
+const (
+	emojiAdvance = 1.1
+	emojiMargin  = (emojiAdvance - 1) / 2
+	emojiAscent  = 0.88
+)
+
+// drawString adds s to the scene like gg's DrawStringAnchored, but with emoji drawn in colour from the emoji set
+// instead of the font
+func (f *faces) drawString(
+	sc *scene,
+	fontType string,
+	size float64,
+	fill color.Color,
+	s string,
+	x, y, ax, ay float64,
+) {
+	face := f.get(fontType, size)
+	runs := f.manager.emoji.split(s)
+
+	x -= ax * measureRuns(face, runs, size)
+	y += ay * float64(face.Metrics().Height) / 64
+
+	for _, run := range runs {
+		if run.emoji == "" {
+			sc.add(textShape{x: x, y: y, text: run.text, fontType: fontType, size: size, fill: fill})
+			x += measureText(face, run.text)
+			continue
+		}
+
+		img, err := f.manager.emoji.getImage(run.emoji)
+		if err != nil {
+			zap.S().Errorw("Unable to draw emoji", zap.Error(err))
+			continue
+		}
+
+		sc.add(imageShape{img: img, x: x + emojiMargin*size, y: y - emojiAscent*size, width: size, height: size})
+		x += emojiAdvance * size
+	}
+}
+
+// measureString is how wide drawString draws s
+func (f *faces) measureString(fontType string, size float64, s string) float64 {
+	return measureRuns(f.get(fontType, size), f.manager.emoji.split(s), size)
+}
+
+func measureRuns(face font.Face, runs []textRun, size float64) float64 {
+	width := 0.0
+	for _, run := range runs {
+		if run.emoji != "" {
+			width += emojiAdvance * size
+			continue
+		}
+
+		width += measureText(face, run.text)
+	}
+
+	return width
+}
+
+// measureText is how wide text is in face, in whole pixels like gg measures it so layouts match what it draws
+func measureText(face font.Face, text string) float64 {
+	return float64(font.MeasureString(face, text) >> 6)
+}
+
+// fitSize is the size s can be drawn at without being wider than width, which is size when it already fits
+func (f *faces) fitSize(fontType string, size float64, s string, width float64) float64 {
+	if measured := f.measureString(fontType, size, s); measured > width {
+		// Glyphs don't scale exactly with the size, so round down to stay inside
+		return math.Floor(size * width / measured)
+	}
+
+	return size
+}
@@ -0,0 +1,246 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/welps/go-frames-scores/assets"
+)
+
+// DefaultTheme is the built-in theme images are drawn with when nothing else is asked for
+const DefaultTheme = "dark"
+
+// Theme is how images look. Sizes that the layout depends on are bounded, see validate
+type Theme struct {
+	Name    string       `json:"name"`
+	Colors  ThemeColors  `json:"colors"`
+	Fonts   ThemeFonts   `json:"fonts"`
+	Card    ThemeCard    `json:"card"`
+	Spacing ThemeSpacing `json:"spacing"`
+	// hash changes whenever anything drawn differently does, so renders with the theme can be addressed by it
+	hash string
+}
+
+type ThemeColors struct {
+	Background Color `json:"background"`
+	Text       Color `json:"text"`
+	// Muted is for secondary text such as match numbers
+	Muted      Color `json:"muted"`
+	Card       Color `json:"card"`
+	CardText   Color `json:"cardText"`
+	CardBorder Color `json:"cardBorder"`
+	// Accent highlights the team that's winning when the card asks for it
+	Accent Color `json:"accent"`
+	// Live marks what's happening right now, like who's serving
+	Live Color `json:"live"`
+}
+
+// ThemeFonts are the embedded fonts text is drawn with, by filename, and their sizes in pixels of an image
+// baseCanvasSize pixels on its short side
+type ThemeFonts struct {
+	Regular  string  `json:"regular"`
+	Bold     string  `json:"bold"`
+	Title    float64 `json:"title"`
+	Subtitle float64 `json:"subtitle"`
+	Name     float64 `json:"name"`
+	Label    float64 `json:"label"`
+	Footer   float64 `json:"footer"`
+}
+
+type ThemeCard struct {
+	CornerRadius    float64 `json:"cornerRadius"`
+	BorderWidth     float64 `json:"borderWidth"`
+	HighlightWinner bool    `json:"highlightWinner"`
+}
+
+// ThemeSpacing is the margin around the edges of images and the gap between rows of cards. Like the font sizes, they're
+// in pixels of an image baseCanvasSize pixels on its short side and scaled with it
+type ThemeSpacing struct {
+	Margin float64 `json:"margin"`
+	Gap    float64 `json:"gap"`
+}
+
+// Color is written in themes as #rrggbb or #rrggbbaa
+type Color color.NRGBA
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return fmt.Errorf("invalid colour %q, expected #rrggbb or #rrggbbaa", s)
+	}
+
+	*c = Color{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}
+	return nil
+}
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A))
+}
+
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
+
+// validate checks that a theme only uses loaded fonts and sizes the layout has room for
+func (t Theme) validate() error {
+	for _, fontType := range []string{t.Fonts.Regular, t.Fonts.Bold} {
+		if _, ok := fontFallbacks[fontType]; !ok {
+			return fmt.Errorf("theme %s: font %q isn't loaded", t.Name, fontType)
+		}
+	}
+
+	bounds := []struct {
+		name  string
+		value float64
+		min   float64
+		max   float64
+	}{
+		{"fonts.title", t.Fonts.Title, 1, 96},
+		{"fonts.subtitle", t.Fonts.Subtitle, 1, 64},
+		{"fonts.name", t.Fonts.Name, 1, (cardHeight - periodLabelHeight) / 2},
+		{"fonts.label", t.Fonts.Label, 1, periodLabelHeight},
+		{"fonts.footer", t.Fonts.Footer, 1, footerHeight},
+		{"card.cornerRadius", t.Card.CornerRadius, 0, cardHeight / 2},
+		{"card.borderWidth", t.Card.BorderWidth, 0, 10},
+		{"spacing.margin", t.Spacing.Margin, 0, 60},
+		{"spacing.gap", t.Spacing.Gap, 0, maxCardGap},
+	}
+	for _, bound := range bounds {
+		if bound.value < bound.min || bound.value > bound.max {
+			return fmt.Errorf(
+				"theme %s: %s is %g, it must be between %g and %g",
+				t.Name,
+				bound.name,
+				bound.value,
+				bound.min,
+				bound.max,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Themes are the themes images can be drawn with and which one each sport uses unless another is asked for
+type Themes struct {
+	themes       map[string]Theme
+	defaultTheme string
+	sportThemes  map[string]string
+}
+
+// NewThemes loads the built-in themes and any *.json themes in dir, which can replace built-ins of the same name.
+// Themes from dir start from the default theme, so they only need the fields they change. sportThemes maps sport
+// slugs to the theme they're drawn with by default
+func NewThemes(dir string, defaultTheme string, sportThemes map[string]string) (*Themes, error) {
+	themes := &Themes{
+		themes:       make(map[string]Theme),
+		defaultTheme: defaultTheme,
+		sportThemes:  sportThemes,
+	}
+
+	builtIns, err := assets.Embedded.ReadDir(assets.ThemesPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read embedded themes: %w", err)
+	}
+	for _, entry := range builtIns {
+		data, err := assets.Embedded.ReadFile(path.Join(assets.ThemesPath, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read embedded theme %s: %w", entry.Name(), err)
+		}
+		if err := themes.add(entry.Name(), data, Theme{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list themes in %s: %w", dir, err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read theme %s: %w", file, err)
+			}
+			if err := themes.add(filepath.Base(file), data, themes.themes[DefaultTheme]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, ok := themes.themes[defaultTheme]; !ok {
+		return nil, fmt.Errorf("default theme %q doesn't exist", defaultTheme)
+	}
+	for slug, name := range sportThemes {
+		if _, ok := themes.themes[name]; !ok {
+			return nil, fmt.Errorf("theme %q for %s doesn't exist", name, slug)
+		}
+	}
+
+	return themes, nil
+}
+
+// add decodes a theme over base, naming it after its file when it doesn't name itself
+func (t *Themes) add(filename string, data []byte, base Theme) error {
+	theme := base
+	theme.Name = ""
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&theme); err != nil {
+		return fmt.Errorf("unable to parse theme %s: %w", filename, err)
+	}
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	if err := theme.validate(); err != nil {
+		return err
+	}
+
+	// Hash the theme as it was resolved rather than the file, so identical themes share renders
+	resolved, err := json.Marshal(theme)
+	if err != nil {
+		return err
+	}
+	hash := fnv.New64a()
+	hash.Write(resolved)
+	theme.hash = strconv.FormatUint(hash.Sum64(), 36)
+
+	t.themes[theme.Name] = theme
+	return nil
+}
+
+// Has is whether there's a theme called name
+func (t *Themes) Has(name string) bool {
+	_, ok := t.themes[name]
+	return ok
+}
+
+// get returns the theme called name, falling back to the sport's theme and then the default theme when there's no
+// such theme. sportSlug can be empty for images that aren't of a sport
+func (t *Themes) get(name string, sportSlug string) Theme {
+	if theme, ok := t.themes[name]; ok {
+		return theme
+	}
+	if theme, ok := t.themes[t.sportThemes[sportSlug]]; ok {
+		return theme
+	}
+
+	return t.themes[t.defaultTheme]
+}
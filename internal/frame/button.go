@@ -6,11 +6,29 @@ import (
 )
 
 func GetFrameButton(index int, content string) template.HTML {
-	button := fmt.Sprintf(`<meta property="fc:frame:button:%d" content="%s" />`, index, content)
+	button := fmt.Sprintf(`<meta property="fc:frame:button:%d" content="%s" />`, index, template.HTMLEscapeString(content))
 	return template.HTML(button)
 }
 
 func GetFramePostButton(url string) template.HTML {
-	post := fmt.Sprintf(`<meta property="fc:frame:post_url" content="%s" />`, url)
+	post := fmt.Sprintf(`<meta property="fc:frame:post_url" content="%s" />`, template.HTMLEscapeString(url))
 	return template.HTML(post)
 }
+
+func GetFrameImageAspectRatio(aspectRatio string) template.HTML {
+	meta := fmt.Sprintf(
+		`<meta property="fc:frame:image:aspect_ratio" content="%s" />`,
+		template.HTMLEscapeString(aspectRatio),
+	)
+	return template.HTML(meta)
+}
+
+func GetFrameState(state string) template.HTML {
+	meta := fmt.Sprintf(`<meta property="fc:frame:state" content="%s" />`, template.HTMLEscapeString(state))
+	return template.HTML(meta)
+}
+
+func GetFrameInput(placeholder string) template.HTML {
+	meta := fmt.Sprintf(`<meta property="fc:frame:input:text" content="%s" />`, template.HTMLEscapeString(placeholder))
+	return template.HTML(meta)
+}
@@ -1,37 +1,52 @@
 package frame
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/welps/go-frames-scores/internal/drawing"
+	"github.com/welps/go-frames-scores/internal/httpcache"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// immutableCacheControl lets clients and CDNs keep content addressed images for a year without revalidating
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
 type Controller struct {
 	publicURL      string
 	drawingService drawing.Service
+	validator      Validator
+	navigator      *Navigator
+	stateCodec     *StateCodec
 }
 
-func NewController(publicURL string, drawingService drawing.Service) *Controller {
+func NewController(
+	publicURL string,
+	drawingService drawing.Service,
+	validator Validator,
+	navigator *Navigator,
+	stateCodec *StateCodec,
+) *Controller {
 	return &Controller{
 		publicURL:      publicURL,
 		drawingService: drawingService,
+		validator:      validator,
+		navigator:      navigator,
+		stateCodec:     stateCodec,
 	}
 }
 
 func (c *Controller) GetRoot(ctx *gin.Context) {
-	assetPath := c.drawingService.GetAssetPath(0)
-
-	ctx.Header("Cache-Control", "no-cache")
-	ctx.HTML(
-		http.StatusOK, "index.tmpl", gin.H{
-			"image":   fmt.Sprintf("%s/%s", c.publicURL, assetPath),
-			"button1": GetFrameButton(1, "🎾 Tennis"),
-			"button2": GetFrameButton(2, "🏀 Basketball"),
-		},
-	)
+	state := c.navigator.Initial()
+	state.Theme, state.AspectRatio = c.getTheme(ctx), c.getAspectRatio(ctx)
+	c.renderFrame(ctx, state)
 }
 
 func (c *Controller) PostRoot(ctx *gin.Context) {
@@ -42,17 +57,129 @@ func (c *Controller) PostRoot(ctx *gin.Context) {
 	}
 
 	zap.S().Debugw("JSON data", zap.Any("data", data))
-	buttonIndex := data.UntrustedData.ButtonIndex
 
-	assetPath := c.drawingService.GetAssetPath(buttonIndex)
+	action, ok := c.validateAction(ctx, data)
+	if !ok {
+		return
+	}
+
+	// Frames without state press buttons of the initial screen
+	state := c.navigator.Initial()
+	state.Theme, state.AspectRatio = c.getTheme(ctx), c.getAspectRatio(ctx)
+	if action.State != "" {
+		decoded, err := c.stateCodec.Decode(action.State)
+		if err != nil {
+			// States we didn't sign (or signed with an old secret) start over, the button pressed on them can't be
+			// trusted to mean what it did when the frame was rendered
+			zap.S().Debugw("Ignoring frame state", zap.Error(err))
+			c.renderFrame(ctx, state)
+			return
+		}
+		state = decoded
+	}
+
+	next, err := c.navigator.Next(ctx, state, action)
+	if err != nil {
+		zap.S().Errorw("Unable to navigate frame", zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	// Transitions only know about screens, so the theme and aspect ratio are carried over here
+	next.Theme, next.AspectRatio = state.Theme, state.AspectRatio
+
+	c.renderFrame(ctx, next)
+}
+
+// getTheme is the theme asked for in the query, themes that don't exist are ignored
+func (c *Controller) getTheme(ctx *gin.Context) string {
+	theme := ctx.Query("theme")
+	if !c.drawingService.HasTheme(theme) {
+		return ""
+	}
+
+	return theme
+}
+
+// getAspectRatio is the aspect ratio asked for in the query, ones frames don't support are ignored
+func (c *Controller) getAspectRatio(ctx *gin.Context) string {
+	ratio, _ := drawing.ParseAspectRatio(ctx.Query("aspect_ratio"))
+	return string(ratio)
+}
+
+func (c *Controller) renderFrame(ctx *gin.Context, state State) {
+	frame, err := c.navigator.Render(ctx, state)
+	if err != nil {
+		zap.S().Errorw("Unable to render frame", zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	encodedState, err := c.stateCodec.Encode(frame.State)
+	if err != nil {
+		zap.S().Errorw("Unable to encode frame state", zap.Error(err))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// The style is in the post URL as well as the state, so it outlives states that can't be decoded
+	postURL := fmt.Sprintf("%s/", c.publicURL)
+	query := url.Values{}
+	if frame.State.Theme != "" {
+		query.Set("theme", frame.State.Theme)
+	}
+	if frame.State.AspectRatio != "" {
+		query.Set("aspect_ratio", frame.State.AspectRatio)
+	}
+	if len(query) > 0 {
+		postURL = fmt.Sprintf("%s?%s", postURL, query.Encode())
+	}
+
+	aspectRatio := c.drawingService.GetAspectRatio(drawing.AspectRatio(frame.State.AspectRatio))
+	data := gin.H{
+		"image":       fmt.Sprintf("%s/%s", c.publicURL, frame.Image),
+		"aspectRatio": GetFrameImageAspectRatio(string(aspectRatio)),
+		"state":       GetFrameState(encodedState),
+		"postURL":     GetFramePostButton(postURL),
+	}
+	for i, button := range frame.Buttons {
+		data[fmt.Sprintf("button%d", i+1)] = GetFrameButton(i+1, button.Label)
+	}
+	if frame.Input != "" {
+		data["input"] = GetFrameInput(frame.Input)
+	}
+
 	ctx.Header("Cache-Control", "no-cache")
-	ctx.HTML(
-		http.StatusOK, "index.tmpl", gin.H{
-			"image": fmt.Sprintf("%s/%s", c.publicURL, assetPath),
-		},
-	)
+	ctx.HTML(http.StatusOK, "index.tmpl", data)
 }
 
+// validateAction verifies the signed message in the post and aborts the request if it can't be trusted
+func (c *Controller) validateAction(ctx *gin.Context, data Post) (FrameAction, bool) {
+	messageBytes, err := hex.DecodeString(strings.TrimPrefix(data.TrustedData.MessageBytes, "0x"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "messageBytes must be hex encoded"})
+		return FrameAction{}, false
+	}
+
+	action, err := c.validator.Validate(ctx, messageBytes)
+	switch {
+	case err == nil:
+		return action, true
+	case errors.Is(err, ErrMalformedMessage):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrUnknownSigner):
+		zap.S().Warnw("Rejected frame message", zap.Error(err), zap.Int("fid", data.UntrustedData.FID))
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		zap.S().Errorw("Unable to validate frame message", zap.Error(err))
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to validate frame message"})
+	}
+
+	return FrameAction{}, false
+}
+
+// Draw serves generated images. Paths are content addressed, so an image requested by its current version never
+// changes and can be cached for good, while outdated versions are served with the latest data and not cached. Images
+// are encoded as their extension says, or as the Accept header prefers when they don't have one
 func (c *Controller) Draw(ctx *gin.Context) {
 	filename := ctx.Param("filename")
 	if filename == "" {
@@ -60,12 +187,58 @@ func (c *Controller) Draw(ctx *gin.Context) {
 		return
 	}
 
-	buf, err := c.drawingService.DrawFile(ctx, filename)
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	matchID, _ := strconv.Atoi(ctx.Query("id"))
+	params := drawing.AssetParams{
+		Page:    page,
+		MatchID: matchID,
+		Style: drawing.Style{
+			Theme:       ctx.Query("theme"),
+			AspectRatio: drawing.AspectRatio(ctx.Query("aspect_ratio")),
+		},
+	}
+	if path.Ext(filename) == "" {
+		ctx.Header("Vary", "Accept")
+		params.Format, _ = drawing.ParseImageContentType(ctx.NegotiateFormat(drawing.ImageContentTypes()...))
+	}
+	format, ok := c.drawingService.GetImageFormat(filename, params)
+	if !ok {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	// Conditional requests are answered without drawing anything while the version they have is still current
+	if version := c.drawingService.GetAssetVersion(ctx, filename, params); version != "" {
+		etag := fmt.Sprintf(`"%s"`, version)
+		if httpcache.MatchesETag(ctx.GetHeader("If-None-Match"), etag) {
+			ctx.Header("ETag", etag)
+			c.setCacheControl(ctx, version)
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	// The headers describe the version that was drawn, the data may have changed since the one above
+	buf, version, err := c.drawingService.DrawFile(ctx, filename, params)
 	if err != nil {
+		ctx.Header("Cache-Control", "no-cache")
 		ctx.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
+	if version != "" {
+		ctx.Header("ETag", fmt.Sprintf(`"%s"`, version))
+	}
+	c.setCacheControl(ctx, version)
+
+	ctx.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// setCacheControl caches images requested by their current version for good, anything else is revalidated
+func (c *Controller) setCacheControl(ctx *gin.Context, version string) {
+	if version != "" && ctx.Param("version") == version {
+		ctx.Header("Cache-Control", immutableCacheControl)
+		return
+	}
 
 	ctx.Header("Cache-Control", "no-cache")
-	ctx.Data(http.StatusOK, "image/png", buf.Bytes())
 }
@@ -0,0 +1,198 @@
+package frame
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/welps/go-frames-scores/internal/drawing"
+	"github.com/welps/go-frames-scores/templates"
+)
+
+// stubDrawingService draws nothing, frames only need it for their styles
+type stubDrawingService struct {
+	drawing.Service
+}
+
+func (stubDrawingService) HasTheme(name string) bool {
+	return name == ""
+}
+
+func (stubDrawingService) GetAspectRatio(ratio drawing.AspectRatio) drawing.AspectRatio {
+	return drawing.AspectRatio("1.91:1")
+}
+
+// newTestController serves a frame going from a home screen to a next screen, validating messages with registry
+func newTestController(t *testing.T, registry KeyRegistry) *gin.Engine {
+	t.Helper()
+
+	navigator := NewNavigator("home")
+	for _, id := range []ScreenID{"home", "next"} {
+		image := string(id) + ".png"
+		navigator.AddScreen(
+			id, Screen{
+				Image: func(context.Context, State) (string, error) {
+					return image, nil
+				},
+				Buttons: func(context.Context, State) ([]Button, error) {
+					return []Button{{Label: "Next", Action: Action{ID: "next"}}}, nil
+				},
+			},
+		)
+	}
+	navigator.AddTransition(
+		"next", func(_ context.Context, state State, _ Action, _ FrameAction) (State, error) {
+			return State{Screen: "next"}, nil
+		},
+	)
+
+	stateCodec, err := NewStateCodec("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := NewController(
+		"https://frames.example.com",
+		stubDrawingService{},
+		NewSignatureValidator(registry),
+		navigator,
+		stateCodec,
+	)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("").ParseFS(templates.Embedded, "*.tmpl")))
+	r.POST("/", controller.PostRoot)
+
+	return r
+}
+
+func postFrame(t *testing.T, r http.Handler, message []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(Post{TrustedData: TrustedData{MessageBytes: hex.EncodeToString(message)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestControllerPostRootValidation(t *testing.T) {
+	key := newTestKey(t)
+	registry := NewStaticKeyRegistry()
+	registry.Add(testFID, key.Public().(ed25519.PublicKey))
+
+	action := FrameAction{URL: "https://frames.example.com/", ButtonIndex: 1}
+	valid := newTestMessage(t, key, action)
+	tampered := newTamperedMessage(
+		t, key, action, func(message *testMessage) {
+			message.signature[0] ^= 0xff
+		},
+	)
+
+	hub := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		),
+	)
+	defer hub.Close()
+	hubRegistry, err := NewHubKeyRegistry(resty.New(), hub.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		registry   KeyRegistry
+		message    []byte
+		wantStatus int
+	}{
+		{name: "valid signature", registry: registry, message: valid, wantStatus: http.StatusOK},
+		{name: "tampered signature", registry: registry, message: tampered, wantStatus: http.StatusUnauthorized},
+		{
+			name:       "unknown signer",
+			registry:   NewStaticKeyRegistry(),
+			message:    valid,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "hub error",
+			registry:   hubRegistry,
+			message:    valid,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "malformed message",
+			registry:   registry,
+			message:    []byte("not a message"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				w := postFrame(t, newTestController(t, test.registry), test.message)
+				if w.Code != test.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", w.Code, test.wantStatus, w.Body)
+				}
+				if test.wantStatus == http.StatusOK && !strings.Contains(w.Body.String(), "next.png") {
+					t.Errorf("body doesn't show the next screen: %s", w.Body)
+				}
+			},
+		)
+	}
+}
+
+func TestControllerPostRootState(t *testing.T) {
+	key := newTestKey(t)
+	registry := NewStaticKeyRegistry()
+	registry.Add(testFID, key.Public().(ed25519.PublicKey))
+
+	stateCodec, err := NewStateCodec("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := stateCodec.Encode(State{Screen: "home", Actions: []Action{{ID: "next"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		state     string
+		wantImage string
+	}{
+		{name: "no state", wantImage: "next.png"},
+		{name: "signed state", state: signed, wantImage: "next.png"},
+		{name: "forged state", state: signed + "forged", wantImage: "home.png"},
+		{name: "garbage state", state: "garbage", wantImage: "home.png"},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				action := FrameAction{URL: "https://frames.example.com/", ButtonIndex: 1, State: test.state}
+				w := postFrame(t, newTestController(t, registry), newTestMessage(t, key, action))
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+				}
+				if !strings.Contains(w.Body.String(), test.wantImage) {
+					t.Errorf("body doesn't show %s: %s", test.wantImage, w.Body)
+				}
+			},
+		)
+	}
+}
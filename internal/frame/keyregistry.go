@@ -0,0 +1,124 @@
+package frame
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// KeyRegistry knows which Ed25519 signers are allowed to sign messages on behalf of a fid
+type KeyRegistry interface {
+	IsActiveSigner(ctx context.Context, fid uint64, signer ed25519.PublicKey) (bool, error)
+}
+
+type hubKeyRegistry struct {
+	hubURL string
+	apiKey string
+	resty  *resty.Client
+}
+
+// NewHubKeyRegistry asks a Farcaster Hub's HTTP API whether a signer is active. apiKey is optional and only needed
+// for hosted hubs that require one.
+func NewHubKeyRegistry(resty *resty.Client, hubURL, apiKey string) (KeyRegistry, error) {
+	if hubURL == "" {
+		return nil, fmt.Errorf("invalid hub url")
+	}
+
+	return &hubKeyRegistry{
+		resty:  resty,
+		hubURL: hubURL,
+		apiKey: apiKey,
+	}, nil
+}
+
+func (r *hubKeyRegistry) IsActiveSigner(ctx context.Context, fid uint64, signer ed25519.PublicKey) (bool, error) {
+	request := r.resty.R().
+		SetContext(ctx).
+		SetQueryParam("fid", strconv.FormatUint(fid, 10)).
+		SetQueryParam("signer", "0x"+hex.EncodeToString(signer))
+	if r.apiKey != "" {
+		request.SetHeader("api_key", r.apiKey)
+	}
+
+	response, err := request.Get(fmt.Sprintf("%s/v1/onChainSignersByFid", r.hubURL))
+	if err != nil {
+		return false, err
+	}
+
+	status := response.StatusCode()
+	switch {
+	case status == http.StatusOK:
+		return true, nil
+	case isSignerNotFound(status, response.Body()):
+		return false, nil
+	default:
+		// Anything else, like a bad API key or being rate limited, says nothing about the signer
+		return false, fmt.Errorf(
+			"failed to get signer - status code %d, response body: %s",
+			status,
+			response.Body(),
+		)
+	}
+}
+
+// hubError is the body of a Hub's error responses
+type hubError struct {
+	ErrCode string `json:"errCode"`
+}
+
+// isSignerNotFound is true when the Hub says there's no active signer event for the fid. Hubs answer that with a
+// 404, or with a 400 carrying a not_found error code
+func isSignerNotFound(status int, body []byte) bool {
+	switch status {
+	case http.StatusNotFound:
+		return true
+	case http.StatusBadRequest:
+		var hubErr hubError
+		if err := json.Unmarshal(body, &hubErr); err != nil {
+			return false
+		}
+		return strings.HasPrefix(hubErr.ErrCode, "not_found")
+	}
+
+	return false
+}
+
+// StaticKeyRegistry is an in-process KeyRegistry, useful for tests and local development without a Hub
+type StaticKeyRegistry struct {
+	keys  map[uint64][]ed25519.PublicKey
+	mutex *sync.RWMutex
+}
+
+func NewStaticKeyRegistry() *StaticKeyRegistry {
+	return &StaticKeyRegistry{
+		keys:  make(map[uint64][]ed25519.PublicKey),
+		mutex: &sync.RWMutex{},
+	}
+}
+
+func (r *StaticKeyRegistry) Add(fid uint64, signer ed25519.PublicKey) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys[fid] = append(r.keys[fid], signer)
+}
+
+func (r *StaticKeyRegistry) IsActiveSigner(_ context.Context, fid uint64, signer ed25519.PublicKey) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, key := range r.keys[fid] {
+		if key.Equal(signer) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,74 @@
+package frame
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestHubKeyRegistryIsActiveSigner(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantActive bool
+		wantErr    bool
+	}{
+		{name: "active signer", status: http.StatusOK, body: `{"events":[]}`, wantActive: true},
+		{name: "not found", status: http.StatusNotFound},
+		{
+			name:   "no signer event",
+			status: http.StatusBadRequest,
+			body:   `{"errCode":"not_found","details":"onChainEventByFidAndSigner not found"}`,
+		},
+		{
+			name:    "invalid request",
+			status:  http.StatusBadRequest,
+			body:    `{"errCode":"bad_request.validation_failure"}`,
+			wantErr: true,
+		},
+		{name: "bad api key", status: http.StatusUnauthorized, wantErr: true},
+		{name: "forbidden", status: http.StatusForbidden, wantErr: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "hub error", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				hub := httptest.NewServer(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							if r.URL.Path != "/v1/onChainSignersByFid" || r.URL.Query().Get("fid") != "1234" {
+								t.Errorf("unexpected request %s", r.URL)
+							}
+							if r.Header.Get("api_key") != "key" {
+								t.Errorf("api_key = %q, want key", r.Header.Get("api_key"))
+							}
+							w.WriteHeader(test.status)
+							_, _ = w.Write([]byte(test.body))
+						},
+					),
+				)
+				defer hub.Close()
+
+				registry, err := NewHubKeyRegistry(resty.New(), hub.URL, "key")
+				if err != nil {
+					t.Fatal(err)
+				}
+				signer := newTestKey(t).Public().(ed25519.PublicKey)
+
+				active, err := registry.IsActiveSigner(context.Background(), testFID, signer)
+				if (err != nil) != test.wantErr {
+					t.Fatalf("IsActiveSigner() error = %v, want error %t", err, test.wantErr)
+				}
+				if active != test.wantActive {
+					t.Errorf("IsActiveSigner() = %t, want %t", active, test.wantActive)
+				}
+			},
+		)
+	}
+}
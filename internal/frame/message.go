@@ -0,0 +1,209 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Farcaster protobuf enum values we care about, see https://github.com/farcasterxyz/hub-monorepo/tree/main/protobufs
+const (
+	hashSchemeBlake3       = 1
+	signatureSchemeEd25519 = 1
+	messageTypeFrameAction = 13
+)
+
+var ErrMalformedMessage = errors.New("malformed frame message")
+
+// Message is the subset of a Farcaster protobuf Message needed to verify a frame action
+type Message struct {
+	// DataBytes are the serialized MessageData exactly as they were hashed and signed
+	DataBytes       []byte
+	Data            MessageData
+	Hash            []byte
+	HashScheme      int
+	Signature       []byte
+	SignatureScheme int
+	Signer          []byte
+}
+
+type MessageData struct {
+	Type        int
+	FID         uint64
+	Timestamp   uint32
+	Network     int
+	FrameAction FrameAction
+}
+
+// FrameAction is the signed counterpart of UntrustedData
+type FrameAction struct {
+	URL         string
+	ButtonIndex int
+	CastID      CastID
+	InputText   string
+	State       string
+	FID         uint64
+}
+
+type CastID struct {
+	FID  uint64
+	Hash []byte
+}
+
+// DecodeMessage parses a protobuf encoded Farcaster Message without needing the generated types
+func DecodeMessage(b []byte) (Message, error) {
+	var message Message
+	var dataBytes []byte
+
+	err := walkFields(
+		b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+			switch {
+			case num == 1 && typ == protowire.BytesType:
+				dataBytes = value
+			case num == 2 && typ == protowire.BytesType:
+				message.Hash = value
+			case num == 3 && typ == protowire.VarintType:
+				message.HashScheme = int(varint)
+			case num == 4 && typ == protowire.BytesType:
+				message.Signature = value
+			case num == 5 && typ == protowire.VarintType:
+				message.SignatureScheme = int(varint)
+			case num == 6 && typ == protowire.BytesType:
+				message.Signer = value
+			case num == 7 && typ == protowire.BytesType:
+				// data_bytes takes precedence over data when present
+				message.DataBytes = value
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if message.DataBytes == nil {
+		message.DataBytes = dataBytes
+	}
+	if message.DataBytes == nil {
+		return Message{}, fmt.Errorf("%w: missing message data", ErrMalformedMessage)
+	}
+
+	message.Data, err = decodeMessageData(message.DataBytes)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return message, nil
+}
+
+func decodeMessageData(b []byte) (MessageData, error) {
+	var data MessageData
+	err := walkFields(
+		b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+			switch {
+			case num == 1 && typ == protowire.VarintType:
+				data.Type = int(varint)
+			case num == 2 && typ == protowire.VarintType:
+				data.FID = varint
+			case num == 3 && typ == protowire.VarintType:
+				data.Timestamp = uint32(varint)
+			case num == 4 && typ == protowire.VarintType:
+				data.Network = int(varint)
+			case num == 16 && typ == protowire.BytesType:
+				frameAction, err := decodeFrameAction(value)
+				if err != nil {
+					return err
+				}
+				data.FrameAction = frameAction
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return MessageData{}, err
+	}
+
+	data.FrameAction.FID = data.FID
+
+	return data, nil
+}
+
+func decodeFrameAction(b []byte) (FrameAction, error) {
+	var action FrameAction
+	err := walkFields(
+		b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+			switch {
+			case num == 1 && typ == protowire.BytesType:
+				action.URL = string(value)
+			case num == 2 && typ == protowire.VarintType:
+				action.ButtonIndex = int(varint)
+			case num == 3 && typ == protowire.BytesType:
+				castID, err := decodeCastID(value)
+				if err != nil {
+					return err
+				}
+				action.CastID = castID
+			case num == 4 && typ == protowire.BytesType:
+				action.InputText = string(value)
+			case num == 5 && typ == protowire.BytesType:
+				action.State = string(value)
+			}
+			return nil
+		},
+	)
+
+	return action, err
+}
+
+func decodeCastID(b []byte) (CastID, error) {
+	var castID CastID
+	err := walkFields(
+		b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+			switch {
+			case num == 1 && typ == protowire.VarintType:
+				castID.FID = varint
+			case num == 2 && typ == protowire.BytesType:
+				castID.Hash = value
+			}
+			return nil
+		},
+	)
+
+	return castID, err
+}
+
+// walkFields calls fn for every top level field in b, skipping groups and fixed width fields
+func walkFields(
+	b []byte,
+	fn func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error,
+) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %s", ErrMalformedMessage, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %s", ErrMalformedMessage, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+)
+
+// Frames support at most four buttons
+const maxButtons = 4
+
+type ScreenID string
+
+type ActionID string
+
+// Action is what pressing a button does, Value carries whatever the transition needs such as a sport or match
+type Action struct {
+	ID    ActionID `json:"i"`
+	Value int      `json:"v,omitempty"`
+}
+
+type Button struct {
+	Label  string
+	Action Action
+}
+
+// Screen knows how to render the image and buttons for a given state. Input is optional and returns the
+// placeholder of the text input shown on the screen, an empty placeholder hides the input.
+type Screen struct {
+	Image   func(ctx context.Context, state State) (string, error)
+	Buttons func(ctx context.Context, state State) ([]Button, error)
+	Input   func(ctx context.Context, state State) string
+}
+
+// Transition returns the state a user ends up in after pressing a button with action in state
+type Transition func(ctx context.Context, state State, action Action, frameAction FrameAction) (State, error)
+
+// Frame is a rendered screen, ready to be turned into meta tags
+type Frame struct {
+	Image   string
+	Buttons []Button
+	Input   string
+	State   State
+}
+
+// Navigator is a small state machine of screens connected by button actions
+type Navigator struct {
+	initial     ScreenID
+	screens     map[ScreenID]Screen
+	transitions map[ActionID]Transition
+}
+
+func NewNavigator(initial ScreenID) *Navigator {
+	return &Navigator{
+		initial:     initial,
+		screens:     make(map[ScreenID]Screen),
+		transitions: make(map[ActionID]Transition),
+	}
+}
+
+func (n *Navigator) AddScreen(id ScreenID, screen Screen) {
+	n.screens[id] = screen
+}
+
+func (n *Navigator) AddTransition(id ActionID, transition Transition) {
+	n.transitions[id] = transition
+}
+
+// Initial is the state users start in and fall back to when their state can't be trusted
+func (n *Navigator) Initial() State {
+	return State{Screen: n.initial}
+}
+
+// Next applies the action of the button that was pressed in frameAction to state
+func (n *Navigator) Next(ctx context.Context, state State, frameAction FrameAction) (State, error) {
+	actions := state.Actions
+	if len(actions) == 0 {
+		// States from frames rendered before actions were recorded, rebuild the buttons as they'd look now
+		screen, ok := n.screens[state.Screen]
+		if !ok {
+			return n.Initial(), nil
+		}
+		buttons, err := screen.Buttons(ctx, state)
+		if err != nil {
+			return State{}, err
+		}
+		for _, button := range buttons {
+			actions = append(actions, button.Action)
+		}
+	}
+
+	buttonIndex := frameAction.ButtonIndex
+	if buttonIndex < 1 || buttonIndex > len(actions) {
+		// Unknown buttons leave users where they were
+		return state, nil
+	}
+
+	action := actions[buttonIndex-1]
+	transition, ok := n.transitions[action.ID]
+	if !ok {
+		return State{}, fmt.Errorf("no transition for action %s", action.ID)
+	}
+
+	return transition(ctx, state, action, frameAction)
+}
+
+// Render builds the frame for state, recording the actions of the rendered buttons in the returned state
+func (n *Navigator) Render(ctx context.Context, state State) (Frame, error) {
+	screen, ok := n.screens[state.Screen]
+	if !ok {
+		return Frame{}, fmt.Errorf("unknown screen %s", state.Screen)
+	}
+
+	image, err := screen.Image(ctx, state)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	buttons, err := screen.Buttons(ctx, state)
+	if err != nil {
+		return Frame{}, err
+	}
+	if len(buttons) > maxButtons {
+		return Frame{}, fmt.Errorf("screen %s has %d buttons, only %d are allowed", state.Screen, len(buttons), maxButtons)
+	}
+
+	var input string
+	if screen.Input != nil {
+		input = screen.Input(ctx, state)
+	}
+
+	state.Actions = make([]Action, 0, len(buttons))
+	for _, button := range buttons {
+		state.Actions = append(state.Actions, button.Action)
+	}
+
+	return Frame{
+		Image:   image,
+		Buttons: buttons,
+		Input:   input,
+		State:   state,
+	}, nil
+}
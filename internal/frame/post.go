@@ -2,8 +2,10 @@ package frame
 
 type Post struct {
 	UntrustedData UntrustedData `json:"untrustedData"`
+	TrustedData   TrustedData   `json:"trustedData"`
 }
 
+// UntrustedData is only useful for logging, everything we act on comes from TrustedData once it's been validated
 type UntrustedData struct {
 	ButtonIndex int             `json:"buttonIndex"`
 	CastID      UntrustedCastID `json:"castId"`
@@ -14,3 +16,8 @@ type UntrustedCastID struct {
 	FID  int    `json:"fid"`
 	Hash string `json:"hash"`
 }
+
+type TrustedData struct {
+	// MessageBytes is a hex encoded protobuf Farcaster Message containing a FrameAction
+	MessageBytes string `json:"messageBytes" binding:"required"`
+}
@@ -0,0 +1,208 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/welps/go-frames-scores/internal/drawing"
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+)
+
+const (
+	ScreenRoot  ScreenID = "root"
+	ScreenSport ScreenID = "sport"
+	ScreenMatch ScreenID = "match"
+)
+
+const (
+	ActionRoot     ActionID = "root"
+	ActionSport    ActionID = "sport"
+	ActionPrevPage ActionID = "prev"
+	ActionNextPage ActionID = "next"
+	ActionMatch    ActionID = "match"
+	ActionList     ActionID = "list"
+	ActionMore     ActionID = "more"
+)
+
+// The root screen pages through sports, keeping the last button free for "More"
+const sportsPerRootPage = maxButtons - 1
+
+// NewScoresNavigator wires up the screens of the scores frame: root -> sport -> pages of matches -> match
+func NewScoresNavigator(drawingService drawing.Service) *Navigator {
+	navigator := NewNavigator(ScreenRoot)
+
+	navigator.AddScreen(
+		ScreenRoot, Screen{
+			Image: func(_ context.Context, state State) (string, error) {
+				return drawingService.GetRootAssetPath(getStyle(state)), nil
+			},
+			Buttons: func(_ context.Context, state State) ([]Button, error) {
+				return getSportButtons(state.Page), nil
+			},
+		},
+	)
+
+	navigator.AddScreen(
+		ScreenSport, Screen{
+			Image: func(ctx context.Context, state State) (string, error) {
+				gameType := sports.GameType(state.GameType)
+				return drawingService.GetSportAssetPath(ctx, gameType, state.Page, getStyle(state)), nil
+			},
+			Buttons: func(ctx context.Context, state State) ([]Button, error) {
+				buttons := []Button{{Label: "🏠 Back", Action: Action{ID: ActionRoot}}}
+				if state.Page > 0 {
+					buttons = append(buttons, Button{Label: "⬅️ Prev", Action: Action{ID: ActionPrevPage}})
+				}
+				if state.Page < getPageCount(ctx, drawingService, state)-1 {
+					buttons = append(buttons, Button{Label: "Next ➡️", Action: Action{ID: ActionNextPage}})
+				}
+				buttons = append(buttons, Button{Label: "🔍 Details", Action: Action{ID: ActionMatch}})
+				return buttons, nil
+			},
+			Input: func(_ context.Context, _ State) string {
+				return "Match # for details"
+			},
+		},
+	)
+
+	navigator.AddScreen(
+		ScreenMatch, Screen{
+			Image: func(ctx context.Context, state State) (string, error) {
+				return drawingService.GetMatchAssetPath(ctx, state.MatchID, getStyle(state)), nil
+			},
+			Buttons: func(_ context.Context, _ State) ([]Button, error) {
+				return []Button{
+					{Label: "⬅️ Back", Action: Action{ID: ActionList}},
+					{Label: "🏠 Home", Action: Action{ID: ActionRoot}},
+				}, nil
+			},
+		},
+	)
+
+	navigator.AddTransition(
+		ActionRoot, func(_ context.Context, _ State, _ Action, _ FrameAction) (State, error) {
+			return navigator.Initial(), nil
+		},
+	)
+	navigator.AddTransition(
+		ActionMore, func(_ context.Context, state State, action Action, _ FrameAction) (State, error) {
+			return State{Screen: ScreenRoot, Page: action.Value}, nil
+		},
+	)
+	navigator.AddTransition(
+		ActionSport, func(_ context.Context, _ State, action Action, _ FrameAction) (State, error) {
+			return State{Screen: ScreenSport, GameType: action.Value}, nil
+		},
+	)
+	navigator.AddTransition(
+		ActionPrevPage, func(ctx context.Context, state State, _ Action, _ FrameAction) (State, error) {
+			return turnPage(ctx, drawingService, state, -1), nil
+		},
+	)
+	navigator.AddTransition(
+		ActionNextPage, func(ctx context.Context, state State, _ Action, _ FrameAction) (State, error) {
+			return turnPage(ctx, drawingService, state, 1), nil
+		},
+	)
+	navigator.AddTransition(
+		ActionMatch, func(ctx context.Context, state State, _ Action, frameAction FrameAction) (State, error) {
+			return openMatch(ctx, drawingService, state, frameAction.InputText)
+		},
+	)
+	navigator.AddTransition(
+		ActionList, func(_ context.Context, state State, _ Action, _ FrameAction) (State, error) {
+			return State{Screen: ScreenSport, GameType: state.GameType, Page: state.Page}, nil
+		},
+	)
+
+	return navigator
+}
+
+// openMatch opens the match numbered input on the current page, staying on the page if there's no such match
+func openMatch(ctx context.Context, drawingService drawing.Service, state State, input string) (State, error) {
+	stay := State{Screen: state.Screen, GameType: state.GameType, Page: state.Page}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(input), "#"))
+	if err != nil {
+		return stay, nil
+	}
+
+	matches, err := drawingService.GetPageMatches(
+		ctx,
+		sports.GameType(state.GameType),
+		state.Page,
+		drawing.AspectRatio(state.AspectRatio),
+	)
+	if err != nil {
+		return State{}, err
+	}
+	if number < 1 || number > len(matches) {
+		return stay, nil
+	}
+
+	return State{
+		Screen:   ScreenMatch,
+		GameType: state.GameType,
+		Page:     state.Page,
+		MatchID:  matches[number-1].ID,
+	}, nil
+}
+
+// getSportButtons returns a button per sport on a page of the root screen, the "More" button wraps around to the
+// first page
+func getSportButtons(page int) []Button {
+	allSports := sports.Sports()
+	perPage := maxButtons
+	if len(allSports) > maxButtons {
+		perPage = sportsPerRootPage
+	}
+	pages := (len(allSports) + perPage - 1) / perPage
+	page = max(0, min(page, pages-1))
+
+	buttons := make([]Button, 0, maxButtons)
+	for _, sport := range lo.Slice(allSports, page*perPage, (page+1)*perPage) {
+		buttons = append(
+			buttons, Button{
+				Label:  fmt.Sprintf("%s %s", sport.Emoji, sport.DisplayName),
+				Action: Action{ID: ActionSport, Value: int(sport.GameType)},
+			},
+		)
+	}
+	if pages > 1 {
+		buttons = append(buttons, Button{Label: "More ➡️", Action: Action{ID: ActionMore, Value: (page + 1) % pages}})
+	}
+
+	return buttons
+}
+
+// turnPage moves state by delta pages, staying within the pages the sport has right now
+func turnPage(ctx context.Context, drawingService drawing.Service, state State, delta int) State {
+	pages := getPageCount(ctx, drawingService, state)
+	return State{
+		Screen:   state.Screen,
+		GameType: state.GameType,
+		Page:     max(0, min(state.Page+delta, pages-1)),
+	}
+}
+
+func getPageCount(ctx context.Context, drawingService drawing.Service, state State) int {
+	pages, err := drawingService.GetPageCount(
+		ctx,
+		sports.GameType(state.GameType),
+		drawing.AspectRatio(state.AspectRatio),
+	)
+	if err != nil {
+		zap.S().Warnw("Unable to count pages", zap.Error(err))
+	}
+
+	return pages
+}
+
+// getStyle is how the state's images are drawn
+func getStyle(state State) drawing.Style {
+	return drawing.Style{Theme: state.Theme, AspectRatio: drawing.AspectRatio(state.AspectRatio)}
+}
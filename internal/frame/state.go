@@ -0,0 +1,101 @@
+package frame
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Frames are only allowed to round trip 4096 bytes of state
+const maxStateLength = 4096
+
+var ErrInvalidState = errors.New("invalid frame state")
+
+// State is everything needed to know which screen a user is on and what the buttons they saw do. It's round
+// tripped through fc:frame:state and comes back to us inside the signed frame action.
+type State struct {
+	Screen   ScreenID `json:"s"`
+	GameType int      `json:"g,omitempty"`
+	Page     int      `json:"p,omitempty"`
+	MatchID  int      `json:"m,omitempty"`
+	// Theme is the theme images are drawn with, it's kept as users move between screens
+	Theme string `json:"t,omitempty"`
+	// AspectRatio is the shape images are drawn in, it's kept along with the theme
+	AspectRatio string `json:"r,omitempty"`
+	// Actions are the actions of the buttons as they were rendered, indexed by button index - 1
+	Actions []Action `json:"a,omitempty"`
+}
+
+// StateCodec serializes State and signs it so users can't hand us a state we never rendered
+type StateCodec struct {
+	secret []byte
+}
+
+// NewStateCodec returns a codec signing with secret. An empty secret generates a random one, which means states
+// rendered before a restart fall back to the root screen.
+func NewStateCodec(secret string) (*StateCodec, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("unable to generate state secret: %w", err)
+		}
+	}
+
+	return &StateCodec{secret: key}, nil
+}
+
+func (c *StateCodec) Encode(state State) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := fmt.Sprintf(
+		"%s.%s",
+		base64.RawURLEncoding.EncodeToString(payload),
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)),
+	)
+	if len(encoded) > maxStateLength {
+		return "", fmt.Errorf("%w: state is %d bytes", ErrInvalidState, len(encoded))
+	}
+
+	return encoded, nil
+}
+
+func (c *StateCodec) Decode(encoded string) (State, error) {
+	payloadPart, signaturePart, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return State{}, fmt.Errorf("%w: missing signature", ErrInvalidState)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return State{}, fmt.Errorf("%w: %w", ErrInvalidState, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(signaturePart)
+	if err != nil {
+		return State{}, fmt.Errorf("%w: %w", ErrInvalidState, err)
+	}
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return State{}, fmt.Errorf("%w: signature mismatch", ErrInvalidState)
+	}
+
+	var state State
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return State{}, fmt.Errorf("%w: %w", ErrInvalidState, err)
+	}
+
+	return state, nil
+}
+
+func (c *StateCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,79 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/zeebo/blake3"
+)
+
+// Farcaster message hashes are blake3 digests truncated to 20 bytes
+const messageHashLength = 20
+
+var (
+	ErrInvalidSignature = errors.New("invalid frame message signature")
+	ErrUnknownSigner    = errors.New("signer is not registered for fid")
+)
+
+// Validator verifies a signed frame message and returns the action that was signed
+type Validator interface {
+	Validate(ctx context.Context, messageBytes []byte) (FrameAction, error)
+}
+
+type signatureValidator struct {
+	keyRegistry KeyRegistry
+}
+
+// NewSignatureValidator verifies hashes and Ed25519 signatures locally and asks keyRegistry whether the signer
+// belongs to the fid that signed the message
+func NewSignatureValidator(keyRegistry KeyRegistry) Validator {
+	return &signatureValidator{
+		keyRegistry: keyRegistry,
+	}
+}
+
+func (v *signatureValidator) Validate(ctx context.Context, messageBytes []byte) (FrameAction, error) {
+	message, err := DecodeMessage(messageBytes)
+	if err != nil {
+		return FrameAction{}, err
+	}
+
+	if message.Data.Type != messageTypeFrameAction {
+		return FrameAction{}, fmt.Errorf("%w: unexpected message type %d", ErrMalformedMessage, message.Data.Type)
+	}
+
+	if message.HashScheme != hashSchemeBlake3 {
+		return FrameAction{}, fmt.Errorf("%w: unsupported hash scheme %d", ErrInvalidSignature, message.HashScheme)
+	}
+	digest := blake3.Sum256(message.DataBytes)
+	if !bytes.Equal(digest[:messageHashLength], message.Hash) {
+		return FrameAction{}, fmt.Errorf("%w: hash does not match message data", ErrInvalidSignature)
+	}
+
+	if message.SignatureScheme != signatureSchemeEd25519 {
+		return FrameAction{}, fmt.Errorf(
+			"%w: unsupported signature scheme %d",
+			ErrInvalidSignature,
+			message.SignatureScheme,
+		)
+	}
+	if len(message.Signer) != ed25519.PublicKeySize {
+		return FrameAction{}, fmt.Errorf("%w: invalid signer length %d", ErrInvalidSignature, len(message.Signer))
+	}
+	if !ed25519.Verify(message.Signer, message.Hash, message.Signature) {
+		return FrameAction{}, fmt.Errorf("%w: signature verification failed", ErrInvalidSignature)
+	}
+
+	active, err := v.keyRegistry.IsActiveSigner(ctx, message.Data.FID, message.Signer)
+	if err != nil {
+		return FrameAction{}, fmt.Errorf("unable to look up signer: %w", err)
+	}
+	if !active {
+		return FrameAction{}, fmt.Errorf("%w %d", ErrUnknownSigner, message.Data.FID)
+	}
+
+	return message.Data.FrameAction, nil
+}
@@ -0,0 +1,192 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/zeebo/blake3"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const testFID = 1234
+
+// testMessage is a signed message before it's encoded, so tests can tamper with its parts
+type testMessage struct {
+	data      []byte
+	hash      []byte
+	signature []byte
+	signer    []byte
+}
+
+// newTestMessage encodes a frame action signed by key the way Farcaster clients do
+func newTestMessage(t *testing.T, key ed25519.PrivateKey, action FrameAction) []byte {
+	t.Helper()
+
+	return newTamperedMessage(t, key, action, func(*testMessage) {})
+}
+
+// newTamperedMessage signs a frame action with key, then lets tamper change the message before it's encoded
+func newTamperedMessage(
+	t *testing.T,
+	key ed25519.PrivateKey,
+	action FrameAction,
+	tamper func(message *testMessage),
+) []byte {
+	t.Helper()
+
+	var frameAction []byte
+	frameAction = protowire.AppendTag(frameAction, 1, protowire.BytesType)
+	frameAction = protowire.AppendString(frameAction, action.URL)
+	frameAction = protowire.AppendTag(frameAction, 2, protowire.VarintType)
+	frameAction = protowire.AppendVarint(frameAction, uint64(action.ButtonIndex))
+	if action.State != "" {
+		frameAction = protowire.AppendTag(frameAction, 5, protowire.BytesType)
+		frameAction = protowire.AppendString(frameAction, action.State)
+	}
+
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, messageTypeFrameAction)
+	data = protowire.AppendTag(data, 2, protowire.VarintType)
+	data = protowire.AppendVarint(data, testFID)
+	data = protowire.AppendTag(data, 16, protowire.BytesType)
+	data = protowire.AppendBytes(data, frameAction)
+
+	digest := blake3.Sum256(data)
+	parts := testMessage{
+		data:      data,
+		hash:      digest[:messageHashLength],
+		signature: ed25519.Sign(key, digest[:messageHashLength]),
+		signer:    key.Public().(ed25519.PublicKey),
+	}
+	tamper(&parts)
+
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.BytesType)
+	message = protowire.AppendBytes(message, parts.data)
+	message = protowire.AppendTag(message, 2, protowire.BytesType)
+	message = protowire.AppendBytes(message, parts.hash)
+	message = protowire.AppendTag(message, 3, protowire.VarintType)
+	message = protowire.AppendVarint(message, hashSchemeBlake3)
+	message = protowire.AppendTag(message, 4, protowire.BytesType)
+	message = protowire.AppendBytes(message, parts.signature)
+	message = protowire.AppendTag(message, 5, protowire.VarintType)
+	message = protowire.AppendVarint(message, signatureSchemeEd25519)
+	message = protowire.AppendTag(message, 6, protowire.BytesType)
+	message = protowire.AppendBytes(message, parts.signer)
+
+	return message
+}
+
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+type failingKeyRegistry struct{}
+
+func (failingKeyRegistry) IsActiveSigner(context.Context, uint64, ed25519.PublicKey) (bool, error) {
+	return false, errors.New("hub unavailable")
+}
+
+func TestSignatureValidatorValidate(t *testing.T) {
+	key := newTestKey(t)
+	registry := NewStaticKeyRegistry()
+	registry.Add(testFID, key.Public().(ed25519.PublicKey))
+
+	action := FrameAction{URL: "https://example.com/", ButtonIndex: 2, State: "state"}
+	valid := newTestMessage(t, key, action)
+	// Pressing another button than the one that was signed
+	tamperedData := newTamperedMessage(
+		t, key, action, func(message *testMessage) {
+			other := action
+			other.ButtonIndex = 4
+			message.data = bytes.Replace(
+				message.data,
+				protowire.AppendVarint([]byte{0x10}, uint64(action.ButtonIndex)),
+				protowire.AppendVarint([]byte{0x10}, uint64(other.ButtonIndex)),
+				1,
+			)
+		},
+	)
+	// Rehashing the tampered data still leaves a signature of the original hash
+	tamperedHash := newTamperedMessage(
+		t, key, action, func(message *testMessage) {
+			message.data = append(message.data, 0x18, 0x01)
+			digest := blake3.Sum256(message.data)
+			message.hash = digest[:messageHashLength]
+		},
+	)
+	tamperedSignature := newTamperedMessage(
+		t, key, action, func(message *testMessage) {
+			message.signature[0] ^= 0xff
+		},
+	)
+
+	tests := []struct {
+		name     string
+		registry KeyRegistry
+		message  []byte
+		wantErr  error
+	}{
+		{name: "valid signature", registry: registry, message: valid},
+		{name: "tampered data", registry: registry, message: tamperedData, wantErr: ErrInvalidSignature},
+		{name: "tampered hash", registry: registry, message: tamperedHash, wantErr: ErrInvalidSignature},
+		{
+			name:     "tampered signature",
+			registry: registry,
+			message:  tamperedSignature,
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "unknown signer",
+			registry: NewStaticKeyRegistry(),
+			message:  valid,
+			wantErr:  ErrUnknownSigner,
+		},
+		{
+			name:     "signed by another key",
+			registry: registry,
+			message:  newTestMessage(t, newTestKey(t), action),
+			wantErr:  ErrUnknownSigner,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				got, err := NewSignatureValidator(test.registry).Validate(context.Background(), test.message)
+				if test.wantErr != nil {
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("Validate() error = %v, want %v", err, test.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if got.URL != action.URL || got.ButtonIndex != action.ButtonIndex || got.State != action.State {
+					t.Errorf("Validate() = %+v, want %+v", got, action)
+				}
+			},
+		)
+	}
+}
+
+func TestSignatureValidatorRegistryError(t *testing.T) {
+	message := newTestMessage(t, newTestKey(t), FrameAction{ButtonIndex: 1})
+
+	_, err := NewSignatureValidator(failingKeyRegistry{}).Validate(context.Background(), message)
+	if err == nil || errors.Is(err, ErrUnknownSigner) || errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Validate() error = %v, want a registry error", err)
+	}
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Migrations are applied in order of their numeric prefix, e.g. 0002_add_leagues.sql, and never edited once released
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrate applies every migration the database hasn't had yet, each in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at INTEGER NOT NULL)`,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to create schema migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+
+	pending, err := getMigrations(current)
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("unable to apply migration %s: %w", m.name, err)
+		}
+		zap.S().Infow("Applied history migration", zap.String("name", m.name))
+	}
+
+	return nil
+}
+
+// getMigrations returns the migrations after version, ReadDir sorts them by name so they're in order
+func getMigrations(after int) ([]migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []migration
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s isn't named version_description.sql", entry.Name())
+		}
+		if version <= after {
+			continue
+		}
+
+		data, err := migrations.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, migration{version: version, name: entry.Name(), sql: string(data)})
+	}
+
+	return pending, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rolling back after committing does nothing
+	// nolint: errcheck
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		m.version,
+		time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,34 @@
+-- Times are milliseconds since the epoch, matches keep their latest state as JSON along with the columns they're
+-- looked up by
+CREATE TABLE matches (
+    id INTEGER PRIMARY KEY,
+    sport TEXT NOT NULL,
+    status TEXT NOT NULL,
+    seen_live INTEGER NOT NULL DEFAULT 0,
+    first_seen_at INTEGER NOT NULL,
+    last_seen_at INTEGER NOT NULL,
+    finished_at INTEGER,
+    data TEXT NOT NULL
+);
+
+CREATE INDEX matches_finished_at ON matches (finished_at) WHERE finished_at IS NOT NULL;
+CREATE INDEX matches_unfinished_live ON matches (sport) WHERE seen_live = 1 AND finished_at IS NULL;
+
+-- Names are lower case, a team is found by any of its name, short name or code
+CREATE TABLE match_teams (
+    match_id INTEGER NOT NULL REFERENCES matches (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    PRIMARY KEY (match_id, name)
+);
+
+CREATE INDEX match_teams_name ON match_teams (name);
+
+-- A snapshot is only recorded when a match's status or score changes
+CREATE TABLE snapshots (
+    match_id INTEGER NOT NULL REFERENCES matches (id) ON DELETE CASCADE,
+    seen_at INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    status_more TEXT NOT NULL,
+    score TEXT NOT NULL,
+    PRIMARY KEY (match_id, seen_at)
+);
@@ -0,0 +1,175 @@
+package history
+
+import (
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// matchRecord is how a match is stored. It's kept apart from sports.Match so changing that doesn't change what's
+// stored and leave earlier rows unreadable. The game type isn't stored, it comes from the sport's slug
+type matchRecord struct {
+	ID                   int         `json:"id"`
+	Home                 teamRecord  `json:"home"`
+	Away                 teamRecord  `json:"away"`
+	Score                scoreRecord `json:"score"`
+	League               string      `json:"league"`
+	Season               string      `json:"season"`
+	Status               string      `json:"status"`
+	StatusMore           string      `json:"status_more"`
+	StartAt              time.Time   `json:"start_at"`
+	CurrentPeriodStartAt time.Time   `json:"current_period_start_at"`
+}
+
+type teamRecord struct {
+	Name        string `json:"name"`
+	ShortName   string `json:"short_name"`
+	Code        string `json:"code"`
+	Logo        string `json:"logo"`
+	Flag        string `json:"flag"`
+	CountryCode string `json:"country_code"`
+}
+
+// scoreRecord is also what snapshots store, they're compared as stored to tell whether the score changed
+type scoreRecord struct {
+	Periods   []string      `json:"periods"`
+	Home      []string      `json:"home"`
+	HomeTotal string        `json:"home_total"`
+	Away      []string      `json:"away"`
+	AwayTotal string        `json:"away_total"`
+	Tennis    *tennisRecord `json:"tennis"`
+}
+
+type tennisRecord struct {
+	HomeSets  int               `json:"home_sets"`
+	AwaySets  int               `json:"away_sets"`
+	Sets      []tennisSetRecord `json:"sets"`
+	HomePoint string            `json:"home_point"`
+	AwayPoint string            `json:"away_point"`
+	// Server is "home", "away" or empty, sides are only numbered in memory
+	Server string `json:"server"`
+}
+
+type tennisSetRecord struct {
+	HomeGames    int    `json:"home_games"`
+	AwayGames    int    `json:"away_games"`
+	HomeTiebreak string `json:"home_tiebreak"`
+	AwayTiebreak string `json:"away_tiebreak"`
+}
+
+// Sides as they're stored
+const (
+	homeSide = "home"
+	awaySide = "away"
+)
+
+func newMatchRecord(match sports.Match) matchRecord {
+	return matchRecord{
+		ID:                   match.ID,
+		Home:                 newTeamRecord(match.Home),
+		Away:                 newTeamRecord(match.Away),
+		Score:                newScoreRecord(match.Score),
+		League:               match.League,
+		Season:               match.Season,
+		Status:               match.Status,
+		StatusMore:           match.StatusMore,
+		StartAt:              match.StartAt,
+		CurrentPeriodStartAt: match.CurrentPeriodStartAt,
+	}
+}
+
+func (r matchRecord) toMatch(gameType sports.GameType) sports.Match {
+	return sports.Match{
+		ID:                   r.ID,
+		GameType:             gameType,
+		Home:                 r.Home.toTeam(),
+		Away:                 r.Away.toTeam(),
+		Score:                r.Score.toScore(),
+		League:               r.League,
+		Season:               r.Season,
+		Status:               r.Status,
+		StatusMore:           r.StatusMore,
+		StartAt:              r.StartAt,
+		CurrentPeriodStartAt: r.CurrentPeriodStartAt,
+	}
+}
+
+func newTeamRecord(team sports.Team) teamRecord {
+	return teamRecord{
+		Name:        team.Name,
+		ShortName:   team.ShortName,
+		Code:        team.Code,
+		Logo:        team.Logo,
+		Flag:        team.Flag,
+		CountryCode: team.CountryCode,
+	}
+}
+
+func (r teamRecord) toTeam() sports.Team {
+	return sports.Team{
+		Name:        r.Name,
+		ShortName:   r.ShortName,
+		Code:        r.Code,
+		Logo:        r.Logo,
+		Flag:        r.Flag,
+		CountryCode: r.CountryCode,
+	}
+}
+
+func newScoreRecord(score sports.Score) scoreRecord {
+	record := scoreRecord{
+		Periods:   score.Periods,
+		Home:      score.Home,
+		HomeTotal: score.HomeTotal,
+		Away:      score.Away,
+		AwayTotal: score.AwayTotal,
+	}
+	if score.Tennis != nil {
+		record.Tennis = &tennisRecord{
+			HomeSets:  score.Tennis.HomeSets,
+			AwaySets:  score.Tennis.AwaySets,
+			HomePoint: score.Tennis.HomePoint,
+			AwayPoint: score.Tennis.AwayPoint,
+		}
+		switch score.Tennis.Server {
+		case sports.HomeSide:
+			record.Tennis.Server = homeSide
+		case sports.AwaySide:
+			record.Tennis.Server = awaySide
+		}
+		for _, set := range score.Tennis.Sets {
+			record.Tennis.Sets = append(record.Tennis.Sets, tennisSetRecord(set))
+		}
+	}
+
+	return record
+}
+
+func (r scoreRecord) toScore() sports.Score {
+	score := sports.Score{
+		Periods:   r.Periods,
+		Home:      r.Home,
+		HomeTotal: r.HomeTotal,
+		Away:      r.Away,
+		AwayTotal: r.AwayTotal,
+	}
+	if r.Tennis != nil {
+		score.Tennis = &sports.TennisScore{
+			HomeSets:  r.Tennis.HomeSets,
+			AwaySets:  r.Tennis.AwaySets,
+			HomePoint: r.Tennis.HomePoint,
+			AwayPoint: r.Tennis.AwayPoint,
+		}
+		switch r.Tennis.Server {
+		case homeSide:
+			score.Tennis.Server = sports.HomeSide
+		case awaySide:
+			score.Tennis.Server = sports.AwaySide
+		}
+		for _, set := range r.Tennis.Sets {
+			score.Tennis.Sets = append(score.Tennis.Sets, sports.TennisSet(set))
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,299 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+	// Registers the pure Go sqlite driver, so there's no cgo to build
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteRepository keeps the history of matches in a SQLite database
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository opens the database at path, creating it if need be, and migrates it to the latest schema
+func NewSQLiteRepository(ctx context.Context, path string) (*SQLiteRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create history directory: %w", err)
+	}
+
+	db, err := sql.Open(
+		"sqlite",
+		path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open history: %w", err)
+	}
+	// SQLite only has one writer at a time, sharing a connection queues sports updating at once rather than having
+	// them wait on each other's locks
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &SQLiteRepository{db: db}, nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLiteRepository) SaveMatches(
+	ctx context.Context,
+	gameType sports.GameType,
+	live bool,
+	matches []sports.Match,
+	seenAt time.Time,
+) error {
+	sport, ok := sports.GetSport(gameType)
+	if !ok {
+		return fmt.Errorf("unknown game type %s", gameType)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rolling back after committing does nothing
+	// nolint: errcheck
+	defer tx.Rollback()
+
+	at := seenAt.UnixMilli()
+	ids := make([]int, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+		if err := saveMatch(ctx, tx, sport.Slug, live, match, at); err != nil {
+			return fmt.Errorf("unable to save match %d: %w", match.ID, err)
+		}
+	}
+
+	if live {
+		// Matches often drop out of the live feed without the provider ever saying they finished
+		encodedIDs, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE matches SET finished_at = ?
+			WHERE sport = ? AND seen_live = 1 AND finished_at IS NULL AND id NOT IN (SELECT value FROM json_each(?))`,
+			at,
+			sport.Slug,
+			string(encodedIDs),
+		)
+		if err != nil {
+			return fmt.Errorf("unable to finish matches that left the live feed: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// saveMatch updates a match's latest state, recording a snapshot when it differs from the last one. Matches that
+// come back to the live feed after dropping out of it weren't finished after all
+func saveMatch(ctx context.Context, tx *sql.Tx, sport string, live bool, match sports.Match, at int64) error {
+	data, err := json.Marshal(newMatchRecord(match))
+	if err != nil {
+		return err
+	}
+	score, err := json.Marshal(newScoreRecord(match.Score))
+	if err != nil {
+		return err
+	}
+
+	var finishedAt sql.NullInt64
+	if match.Status == sports.MatchStatusFinished {
+		finishedAt = sql.NullInt64{Int64: at, Valid: true}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO matches (id, sport, status, seen_live, first_seen_at, last_seen_at, finished_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			seen_live = MAX(seen_live, excluded.seen_live),
+			last_seen_at = excluded.last_seen_at,
+			finished_at = CASE
+				WHEN excluded.finished_at IS NOT NULL THEN COALESCE(finished_at, excluded.finished_at)
+				WHEN excluded.seen_live = 1 THEN NULL
+				ELSE finished_at
+			END,
+			data = excluded.data`,
+		match.ID,
+		sport,
+		match.Status,
+		live,
+		at,
+		at,
+		finishedAt,
+		string(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range []sports.Team{match.Home, match.Away} {
+		for _, name := range []string{team.Name, team.ShortName, team.Code} {
+			if name == "" {
+				continue
+			}
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT OR IGNORE INTO match_teams (match_id, name) VALUES (?, ?)`,
+				match.ID,
+				normalizeTeam(name),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO snapshots (match_id, seen_at, status, status_more, score)
+		SELECT ?1, ?2, ?3, ?4, ?5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM (SELECT status, status_more, score FROM snapshots WHERE match_id = ?1
+				ORDER BY seen_at DESC LIMIT 1) AS latest
+			WHERE latest.status = ?3 AND latest.status_more = ?4 AND latest.score = ?5
+		)`,
+		match.ID,
+		at,
+		match.Status,
+		match.StatusMore,
+		string(score),
+	)
+
+	return err
+}
+
+func (r *SQLiteRepository) GetResults(ctx context.Context, from time.Time, to time.Time) ([]sports.Result, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT sport, data, finished_at FROM matches
+		WHERE finished_at >= ? AND finished_at < ?
+		ORDER BY finished_at DESC, id`,
+		from.UnixMilli(),
+		to.UnixMilli(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanResults(rows)
+}
+
+func (r *SQLiteRepository) GetTeamResults(ctx context.Context, team string, limit int) ([]sports.Result, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT sport, data, finished_at FROM matches
+		WHERE finished_at IS NOT NULL AND id IN (SELECT match_id FROM match_teams WHERE name = ?)
+		ORDER BY finished_at DESC, id
+		LIMIT ?`,
+		normalizeTeam(team),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanResults(rows)
+}
+
+func (r *SQLiteRepository) GetTimeline(ctx context.Context, id int) (sports.Match, []sports.Snapshot, error) {
+	var sport, data string
+	err := r.db.QueryRowContext(ctx, `SELECT sport, data FROM matches WHERE id = ?`, id).Scan(&sport, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sports.Match{}, nil, fmt.Errorf("%w: %d", sports.ErrMatchNotFound, id)
+	}
+	if err != nil {
+		return sports.Match{}, nil, err
+	}
+	match, err := decodeMatch(sport, data)
+	if err != nil {
+		return sports.Match{}, nil, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT seen_at, status, status_more, score FROM snapshots WHERE match_id = ? ORDER BY seen_at`,
+		id,
+	)
+	if err != nil {
+		return sports.Match{}, nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []sports.Snapshot
+	for rows.Next() {
+		var seenAt int64
+		var score string
+		snapshot := sports.Snapshot{}
+		if err := rows.Scan(&seenAt, &snapshot.Status, &snapshot.StatusMore, &score); err != nil {
+			return sports.Match{}, nil, err
+		}
+		var record scoreRecord
+		if err := json.Unmarshal([]byte(score), &record); err != nil {
+			return sports.Match{}, nil, fmt.Errorf("unable to decode snapshot of match %d: %w", id, err)
+		}
+		snapshot.Score = record.toScore()
+		snapshot.SeenAt = time.UnixMilli(seenAt).UTC()
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return match, snapshots, rows.Err()
+}
+
+func scanResults(rows *sql.Rows) ([]sports.Result, error) {
+	defer rows.Close()
+
+	var results []sports.Result
+	for rows.Next() {
+		var sport, data string
+		var finishedAt int64
+		if err := rows.Scan(&sport, &data, &finishedAt); err != nil {
+			return nil, err
+		}
+
+		match, err := decodeMatch(sport, data)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, sports.Result{Match: match, FinishedAt: time.UnixMilli(finishedAt).UTC()})
+	}
+
+	return results, rows.Err()
+}
+
+// decodeMatch reads a match back, its game type comes from the sport's slug as game types are only numbered in
+// memory
+func decodeMatch(sport string, data string) (sports.Match, error) {
+	var record matchRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return sports.Match{}, fmt.Errorf("unable to decode match: %w", err)
+	}
+
+	registered, ok := sports.GetSportBySlug(sport)
+	if !ok {
+		return sports.Match{}, fmt.Errorf("match %d is of an unknown sport %q", record.ID, sport)
+	}
+
+	return record.toMatch(registered.GameType), nil
+}
+
+func normalizeTeam(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,390 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+func newTestRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+
+	repository, err := NewSQLiteRepository(context.Background(), filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(
+		func() {
+			_ = repository.Close()
+		},
+	)
+
+	return repository
+}
+
+func getTestTennisMatch() sports.Match {
+	return sports.Match{
+		ID:       42,
+		GameType: sports.Tennis,
+		Home:     sports.Team{Name: "Rafael Nadal", Code: "NAD", CountryCode: "ES", Flag: "https://example.com/es.png"},
+		Away:     sports.Team{Name: "Roger Federer", Code: "FED", CountryCode: "CH"},
+		Score: sports.Score{
+			Home:      []string{"7", "3"},
+			HomeTotal: "1",
+			Away:      []string{"6", "4"},
+			AwayTotal: "0",
+			Tennis: &sports.TennisScore{
+				HomeSets: 1,
+				Sets: []sports.TennisSet{
+					{HomeGames: 7, AwayGames: 6, HomeTiebreak: "7", AwayTiebreak: "5"},
+					{HomeGames: 3, AwayGames: 4},
+				},
+				HomePoint: "30",
+				AwayPoint: "AD",
+				Server:    sports.AwaySide,
+			},
+		},
+		League:     "Wimbledon",
+		Season:     "2024",
+		Status:     sports.MatchStatusInProgress,
+		StatusMore: "2nd set",
+		StartAt:    time.Date(2024, 7, 1, 13, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSQLiteRepositoryTimeline(t *testing.T) {
+	repository := newTestRepository(t)
+	ctx := context.Background()
+	match := getTestTennisMatch()
+	seenAt := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+
+	// Snapshots are only recorded when the score changes
+	changed := match
+	changed.Score.Tennis = &sports.TennisScore{HomeSets: 1, HomePoint: "40", AwayPoint: "AD", Server: sports.HomeSide}
+	for i, update := range []sports.Match{match, match, changed} {
+		err := repository.SaveMatches(
+			ctx,
+			sports.Tennis,
+			true,
+			[]sports.Match{update},
+			seenAt.Add(time.Duration(i)*time.Minute),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, snapshots, err := repository.GetTimeline(ctx, match.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, changed) {
+		t.Errorf("GetTimeline() match = %+v, want %+v", got, changed)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("GetTimeline() has %d snapshots, want 2", len(snapshots))
+	}
+	if !reflect.DeepEqual(snapshots[0].Score, match.Score) || !reflect.DeepEqual(snapshots[1].Score, changed.Score) {
+		t.Errorf("GetTimeline() snapshots = %+v", snapshots)
+	}
+	if !snapshots[1].SeenAt.Equal(seenAt.Add(2 * time.Minute)) {
+		t.Errorf("latest snapshot was seen at %s, want %s", snapshots[1].SeenAt, seenAt.Add(2*time.Minute))
+	}
+}
+
+// TestSQLiteRepositoryStoredMatch pins the names matches are stored with, renaming them leaves earlier rows unreadable
+func TestSQLiteRepositoryStoredMatch(t *testing.T) {
+	repository := newTestRepository(t)
+	ctx := context.Background()
+	match := getTestTennisMatch()
+	if err := repository.SaveMatches(ctx, sports.Tennis, true, []sports.Match{match}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var data string
+	err := repository.db.QueryRowContext(ctx, `SELECT data FROM matches WHERE id = ?`, match.ID).Scan(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored map[string]any
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path []any
+		want any
+	}{
+		{path: []any{"id"}, want: float64(42)},
+		{path: []any{"home", "country_code"}, want: "ES"},
+		{path: []any{"status_more"}, want: "2nd set"},
+		{path: []any{"score", "tennis", "server"}, want: "away"},
+		{path: []any{"score", "tennis", "sets", 0, "home_tiebreak"}, want: "7"},
+		{path: []any{"game_type"}, want: nil},
+	}
+	for _, test := range tests {
+		t.Run(
+			fmt.Sprint(test.path), func(t *testing.T) {
+				if got := getStoredValue(stored, test.path); got != test.want {
+					t.Errorf("stored %v = %v, want %v in %s", test.path, got, test.want, data)
+				}
+			},
+		)
+	}
+}
+
+// getStoredValue looks up path in decoded JSON, keys are strings and indexes are ints
+func getStoredValue(value any, path []any) any {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, _ := value.(map[string]any)
+			value = object[step]
+		case int:
+			array, _ := value.([]any)
+			if step >= len(array) {
+				return nil
+			}
+			value = array[step]
+		}
+	}
+
+	return value
+}
+
+// testUpdate is an update of a sport's matches, as the sports service saves them
+type testUpdate struct {
+	gameType sports.GameType
+	live     bool
+	matches  []sports.Match
+}
+
+// getTestMatch is a match between teams named after its id, so every match has teams of its own
+func getTestMatch(id int, gameType sports.GameType, status string) sports.Match {
+	return sports.Match{
+		ID:       id,
+		GameType: gameType,
+		Home:     sports.Team{Name: fmt.Sprintf("Home %d", id)},
+		Away:     sports.Team{Name: fmt.Sprintf("Away %d", id)},
+		Status:   status,
+	}
+}
+
+func TestSQLiteRepositoryFinishedMatches(t *testing.T) {
+	playing := getTestMatch(1, sports.Basketball, sports.MatchStatusInProgress)
+	other := getTestMatch(2, sports.Basketball, sports.MatchStatusInProgress)
+	finished := getTestMatch(1, sports.Basketball, sports.MatchStatusFinished)
+	tennis := getTestMatch(3, sports.Tennis, sports.MatchStatusInProgress)
+
+	tests := []struct {
+		name    string
+		updates []testUpdate
+		// wantFinished is the update each finished match was finished by, by match id
+		wantFinished map[int]int
+	}{
+		{
+			name: "finished by the provider",
+			updates: []testUpdate{
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{playing}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{finished}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{finished}},
+			},
+			wantFinished: map[int]int{1: 1},
+		},
+		{
+			name: "left the live feed",
+			updates: []testUpdate{
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{playing, other}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{other}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{}},
+			},
+			wantFinished: map[int]int{1: 1, 2: 2},
+		},
+		{
+			name: "came back to the live feed",
+			updates: []testUpdate{
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{playing, other}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{other}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{playing, other}},
+			},
+			wantFinished: map[int]int{},
+		},
+		{
+			name: "left the live feed and listed with every match",
+			updates: []testUpdate{
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{playing, other}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{other}},
+				{gameType: sports.Basketball, live: false, matches: []sports.Match{playing, other}},
+			},
+			wantFinished: map[int]int{1: 1},
+		},
+		{
+			name: "never live",
+			updates: []testUpdate{
+				{gameType: sports.Basketball, live: false, matches: []sports.Match{playing}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{}},
+			},
+			wantFinished: map[int]int{},
+		},
+		{
+			name: "another sport's live feed",
+			updates: []testUpdate{
+				{gameType: sports.Tennis, live: true, matches: []sports.Match{tennis}},
+				{gameType: sports.Basketball, live: true, matches: []sports.Match{}},
+			},
+			wantFinished: map[int]int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				repository := newTestRepository(t)
+				ctx := context.Background()
+				seenAt := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+				for i, update := range test.updates {
+					err := repository.SaveMatches(
+						ctx,
+						update.gameType,
+						update.live,
+						update.matches,
+						seenAt.Add(time.Duration(i)*time.Minute),
+					)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				results, err := repository.GetResults(ctx, seenAt, seenAt.Add(time.Hour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				finishedAt := make(map[int]time.Time, len(results))
+				for _, result := range results {
+					finishedAt[result.Match.ID] = result.FinishedAt
+				}
+				if len(finishedAt) != len(test.wantFinished) {
+					t.Errorf("finished matches %v, want %v", finishedAt, test.wantFinished)
+				}
+				for id, update := range test.wantFinished {
+					if want := seenAt.Add(time.Duration(update) * time.Minute); !finishedAt[id].Equal(want) {
+						t.Errorf("match %d finished at %s, want %s", id, finishedAt[id], want)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestSQLiteRepositoryGetResults(t *testing.T) {
+	repository := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// Every match is finished by the provider as it's seen, one per update
+	for id, finishedAt := range map[int]time.Time{
+		1: day.Add(-time.Millisecond),
+		2: day,
+		3: day.Add(12 * time.Hour),
+		4: day.Add(24*time.Hour - time.Millisecond),
+		5: day.Add(24 * time.Hour),
+	} {
+		match := getTestMatch(id, sports.Basketball, sports.MatchStatusFinished)
+		if err := repository.SaveMatches(ctx, sports.Basketball, false, []sports.Match{match}, finishedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		wantIDs []int
+	}{
+		{name: "day", from: day, to: day.AddDate(0, 0, 1), wantIDs: []int{4, 3, 2}},
+		{name: "day before", from: day.AddDate(0, 0, -1), to: day, wantIDs: []int{1}},
+		{name: "day after", from: day.AddDate(0, 0, 1), to: day.AddDate(0, 0, 2), wantIDs: []int{5}},
+		{name: "empty range", from: day, to: day},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				results, err := repository.GetResults(ctx, test.from, test.to)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ids := getResultIDs(results); !reflect.DeepEqual(ids, test.wantIDs) {
+					t.Errorf("GetResults() = %v, want %v", ids, test.wantIDs)
+				}
+			},
+		)
+	}
+}
+
+func TestSQLiteRepositoryGetTeamResults(t *testing.T) {
+	repository := newTestRepository(t)
+	ctx := context.Background()
+	seenAt := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+
+	nadal := sports.Team{Name: "Rafael Nadal", ShortName: "R. Nadal", Code: "NAD"}
+	matches := []sports.Match{
+		{ID: 1, Home: nadal, Away: sports.Team{Name: "Roger Federer"}, Status: sports.MatchStatusFinished},
+		{ID: 2, Home: sports.Team{Name: "Novak Djokovic"}, Away: nadal, Status: sports.MatchStatusFinished},
+		{ID: 3, Home: sports.Team{Name: "Andy Murray"}, Away: nadal, Status: sports.MatchStatusInProgress},
+	}
+	for i, match := range matches {
+		err := repository.SaveMatches(
+			ctx,
+			sports.Tennis,
+			false,
+			[]sports.Match{match},
+			seenAt.Add(time.Duration(i)*time.Minute),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		team    string
+		limit   int
+		wantIDs []int
+	}{
+		{name: "name", team: "Rafael Nadal", limit: 10, wantIDs: []int{2, 1}},
+		{name: "short name", team: "R. Nadal", limit: 10, wantIDs: []int{2, 1}},
+		{name: "code", team: "NAD", limit: 10, wantIDs: []int{2, 1}},
+		{name: "differently written", team: "  rafael NADAL ", limit: 10, wantIDs: []int{2, 1}},
+		{name: "part of a name", team: "Nadal", limit: 10},
+		{name: "limited", team: "NAD", limit: 1, wantIDs: []int{2}},
+		{name: "opponent", team: "Roger Federer", limit: 10, wantIDs: []int{1}},
+		{name: "only playing", team: "Andy Murray", limit: 10},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				results, err := repository.GetTeamResults(ctx, test.team, test.limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ids := getResultIDs(results); !reflect.DeepEqual(ids, test.wantIDs) {
+					t.Errorf("GetTeamResults(%q, %d) = %v, want %v", test.team, test.limit, ids, test.wantIDs)
+				}
+			},
+		)
+	}
+}
+
+func getResultIDs(results []sports.Result) []int {
+	var ids []int
+	for _, result := range results {
+		ids = append(ids, result.Match.ID)
+	}
+
+	return ids
+}
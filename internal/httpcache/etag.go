@@ -0,0 +1,15 @@
+package httpcache
+
+import "strings"
+
+// MatchesETag reports whether an If-None-Match header lists etag, comparing weakly as RFC 9110 asks for
+func MatchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package sports
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrSubscriberTooSlow ends subscriptions that fell further behind than their buffer, they can resubscribe
+	ErrSubscriberTooSlow = errors.New("subscriber fell too far behind")
+	// ErrBrokerClosed ends every subscription when the broker shuts down
+	ErrBrokerClosed = errors.New("broker closed")
+)
+
+// Broker hands the events of every update to its subscribers in process. Publishing never waits on subscribers, so
+// one that doesn't keep up is dropped rather than holding up updates
+type Broker struct {
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+	mutex         *sync.Mutex
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: make(map[*Subscription]struct{}),
+		mutex:         &sync.Mutex{},
+	}
+}
+
+// Subscription receives the events of the sports it's for, or of every sport when it's for none in particular
+type Subscription struct {
+	broker    *Broker
+	gameTypes map[GameType]bool
+	events    chan Event
+	err       error
+}
+
+// Subscribe starts receiving events of gameTypes, up to buffer of them can wait to be received before the
+// subscription is dropped
+func (b *Broker) Subscribe(gameTypes []GameType, buffer int) *Subscription {
+	subscription := &Subscription{
+		broker:    b,
+		gameTypes: make(map[GameType]bool, len(gameTypes)),
+		events:    make(chan Event, buffer),
+	}
+	for _, gameType := range gameTypes {
+		subscription.gameTypes[gameType] = true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		subscription.err = ErrBrokerClosed
+		close(subscription.events)
+		return subscription
+	}
+	b.subscriptions[subscription] = struct{}{}
+
+	return subscription
+}
+
+// Publish hands events to every subscription that's for their sport
+func (b *Broker) Publish(events []Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscription := range b.subscriptions {
+		for _, event := range events {
+			if len(subscription.gameTypes) > 0 && !subscription.gameTypes[event.Match.GameType] {
+				continue
+			}
+
+			select {
+			case subscription.events <- event:
+			default:
+				b.remove(subscription, ErrSubscriberTooSlow)
+			}
+			if subscription.err != nil {
+				break
+			}
+		}
+	}
+}
+
+// Close ends every subscription, so streams of events finish when the server shuts down
+func (b *Broker) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscriptions {
+		b.remove(subscription, ErrBrokerClosed)
+	}
+}
+
+// remove closes a subscription's events, the broker's mutex must be held so nothing is published to it after
+func (b *Broker) remove(subscription *Subscription, err error) {
+	if _, ok := b.subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(b.subscriptions, subscription)
+	subscription.err = err
+	close(subscription.events)
+}
+
+// Events are closed once the subscription ends, Err says why
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err is why the subscription ended, it's nil until Events is closed and when it was closed by its subscriber
+func (s *Subscription) Err() error {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	return s.err
+}
+
+// Close stops receiving events
+func (s *Subscription) Close() {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	s.broker.remove(s, nil)
+}
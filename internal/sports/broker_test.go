@@ -0,0 +1,121 @@
+package sports
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func getTestEvent(id int, gameType GameType) Event {
+	return Event{Type: EventScoreChanged, Match: Match{ID: id, GameType: gameType}}
+}
+
+// receiveTestEvents reads the events waiting in a subscription, and whether it's ended
+func receiveTestEvents(subscription *Subscription) ([]int, bool) {
+	var ids []int
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, event.Match.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	events := []Event{getTestEvent(1, Tennis), getTestEvent(2, Basketball), getTestEvent(3, Tennis)}
+
+	tests := []struct {
+		name      string
+		gameTypes []GameType
+		buffer    int
+		wantIDs   []int
+		wantErr   error
+	}{
+		{name: "every sport", buffer: 10, wantIDs: []int{1, 2, 3}},
+		{name: "one sport", gameTypes: []GameType{Tennis}, buffer: 10, wantIDs: []int{1, 3}},
+		{name: "several sports", gameTypes: []GameType{Tennis, Basketball}, buffer: 10, wantIDs: []int{1, 2, 3}},
+		{name: "sport without events", gameTypes: []GameType{Baseball}, buffer: 10},
+		{name: "buffer just big enough", gameTypes: []GameType{Tennis}, buffer: 2, wantIDs: []int{1, 3}},
+		{name: "too slow", buffer: 2, wantIDs: []int{1, 2}, wantErr: ErrSubscriberTooSlow},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				broker := NewBroker()
+				subscription := broker.Subscribe(test.gameTypes, test.buffer)
+				broker.Publish(events)
+
+				ids, ended := receiveTestEvents(subscription)
+				if !slices.Equal(ids, test.wantIDs) {
+					t.Errorf("received %v, want %v", ids, test.wantIDs)
+				}
+				if wantEnded := test.wantErr != nil; ended != wantEnded {
+					t.Errorf("subscription ended %t, want %t", ended, wantEnded)
+				}
+				if err := subscription.Err(); !errors.Is(err, test.wantErr) {
+					t.Errorf("Err() = %v, want %v", err, test.wantErr)
+				}
+			},
+		)
+	}
+}
+
+func TestBrokerTooSlowSubscriberDropped(t *testing.T) {
+	broker := NewBroker()
+	slow := broker.Subscribe(nil, 1)
+	other := broker.Subscribe(nil, 10)
+
+	broker.Publish([]Event{getTestEvent(1, Tennis), getTestEvent(2, Tennis)})
+	// Once dropped, the slow subscriber isn't sent anything more, while the others carry on
+	broker.Publish([]Event{getTestEvent(3, Tennis)})
+
+	if ids, ended := receiveTestEvents(slow); !slices.Equal(ids, []int{1}) || !ended {
+		t.Errorf("slow subscriber received %v and ended %t, want [1] and ended", ids, ended)
+	}
+	if ids, ended := receiveTestEvents(other); !slices.Equal(ids, []int{1, 2, 3}) || ended {
+		t.Errorf("other subscriber received %v and ended %t, want [1 2 3] and not ended", ids, ended)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker()
+	subscription := broker.Subscribe(nil, 10)
+	closed := broker.Subscribe(nil, 10)
+	closed.Close()
+
+	broker.Publish([]Event{getTestEvent(1, Tennis)})
+	broker.Close()
+	// Publishing after closing, as an update still running would, reaches nobody
+	broker.Publish([]Event{getTestEvent(2, Tennis)})
+
+	if ids, ended := receiveTestEvents(subscription); !slices.Equal(ids, []int{1}) || !ended {
+		t.Errorf("received %v and ended %t, want [1] and ended", ids, ended)
+	}
+	if err := subscription.Err(); !errors.Is(err, ErrBrokerClosed) {
+		t.Errorf("Err() = %v, want %v", err, ErrBrokerClosed)
+	}
+
+	// Subscriptions closed by their subscriber end without an error
+	if ids, ended := receiveTestEvents(closed); len(ids) > 0 || !ended {
+		t.Errorf("closed subscription received %v and ended %t", ids, ended)
+	}
+	if err := closed.Err(); err != nil {
+		t.Errorf("closed subscription Err() = %v, want nil", err)
+	}
+
+	late := broker.Subscribe(nil, 10)
+	if _, ended := receiveTestEvents(late); !ended {
+		t.Error("subscription made after closing didn't end")
+	}
+	if err := late.Err(); !errors.Is(err, ErrBrokerClosed) {
+		t.Errorf("late subscription Err() = %v, want %v", err, ErrBrokerClosed)
+	}
+	// Closing twice does nothing
+	late.Close()
+	broker.Close()
+}
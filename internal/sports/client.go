@@ -3,91 +3,194 @@ package sports
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
 type Client interface {
 	GetMatches(ctx context.Context, gameType GameType) (ClientMatchResponse, error)
 	GetLiveMatches(ctx context.Context, gameType GameType) (ClientMatchResponse, error)
+	// Quota is what the provider last reported about the remaining request quota
+	Quota() Quota
+}
+
+// ClientOptions control how hard the client leans on the provider
+type ClientOptions struct {
+	// MaxPages caps how many pages of matches are fetched, zero or less follows every page
+	MaxPages int
+	// MaxRetries is how many times a request is retried after a timeout or a 5xx
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// RequestsPerSecond and Burst size the client side token bucket, zero requests per second disables it
+	RequestsPerSecond float64
+	Burst             int
 }
 
 type client struct {
 	apiHost string
 	apiKey  string
 	resty   *resty.Client
+	options ClientOptions
+	limiter *rate.Limiter
+	// sportIDs caches provider ids looked up by slug for sports without a known ProviderID
+	sportIDs map[string]int
+	mutex    *sync.Mutex
+	// quota is guarded by its own mutex as it's updated while mutex is held to load sport ids
+	quota      Quota
+	quotaMutex *sync.RWMutex
 }
 
-func NewClient(resty *resty.Client, apiHost, apiKey string) (Client, error) {
-	if apiHost == "" || apiKey == "" {
+type clientSportsResponse struct {
+	Sports []ClientSport `json:"data"`
+}
+
+func NewClient(resty *resty.Client, apiHost, apiKey string, options ClientOptions) (Client, error) {
+	switch {
+	case apiHost == "" || apiKey == "":
 		return nil, fmt.Errorf("invalid api host or key")
+	case options.MaxRetries < 0:
+		return nil, fmt.Errorf("sports api max retries can't be negative")
+	case options.RetryBaseDelay < 0 || options.RetryMaxDelay < options.RetryBaseDelay:
+		return nil, fmt.Errorf("sports api retry delays can't be negative, the max must be at least the base")
+	}
+
+	limit := rate.Inf
+	if options.RequestsPerSecond > 0 {
+		limit = rate.Limit(options.RequestsPerSecond)
 	}
 
 	return &client{
-		resty:   resty,
-		apiHost: apiHost,
-		apiKey:  apiKey,
+		resty:      resty,
+		apiHost:    apiHost,
+		apiKey:     apiKey,
+		options:    options,
+		limiter:    rate.NewLimiter(limit, max(options.Burst, 1)),
+		sportIDs:   make(map[string]int),
+		mutex:      &sync.Mutex{},
+		quotaMutex: &sync.RWMutex{},
 	}, nil
 }
 
-func (c *client) getSportsID(gameType GameType) int {
-	switch gameType {
-	case Basketball:
-		return 3
-	case Tennis:
-		return 2
-	default:
-		return 0
-	}
+func (c *client) Quota() Quota {
+	c.quotaMutex.RLock()
+	defer c.quotaMutex.RUnlock()
+
+	return c.quota
 }
 
-func (c *client) GetMatches(ctx context.Context, gameType GameType) (ClientMatchResponse, error) {
-	sportsID := c.getSportsID(gameType)
-	if sportsID == 0 {
-		return ClientMatchResponse{}, fmt.Errorf("invalid game type")
+func (c *client) getSportsID(ctx context.Context, gameType GameType) (int, error) {
+	sport, ok := GetSport(gameType)
+	if !ok {
+		return 0, fmt.Errorf("invalid game type")
+	}
+	if sport.ProviderID != 0 {
+		return sport.ProviderID, nil
 	}
-	// TODO:: Pagination
-	url := fmt.Sprintf("%s/sports/%d/events", c.apiHost, c.getSportsID(gameType))
 
-	response, err := c.resty.R().
-		SetHeader("x-rapidapi-key", c.apiKey).
-		SetContext(ctx).
-		Get(url)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
+	if len(c.sportIDs) == 0 {
+		if err := c.loadSportIDs(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	id, ok := c.sportIDs[sport.ProviderSlug]
+	if !ok {
+		return 0, fmt.Errorf("provider does not support %s", sport.ProviderSlug)
+	}
+
+	return id, nil
+}
+
+// loadSportIDs fetches the provider's sports so ids can be looked up by slug, callers must hold the mutex
+func (c *client) loadSportIDs(ctx context.Context) error {
+	response, err := c.get(ctx, fmt.Sprintf("%s/sports", c.apiHost), nil)
 	if err != nil {
-		return ClientMatchResponse{}, err
+		return err
 	}
 
 	status := response.StatusCode()
 	if status != 200 {
-		return ClientMatchResponse{}, fmt.Errorf(
-			"failed to get scores - status code %d, response body: %s",
+		return fmt.Errorf(
+			"failed to get sports - status code %d, response body: %s",
 			status,
 			response.Body(),
 		)
 	}
 
-	var result ClientMatchResponse
+	var result clientSportsResponse
 	err = json.Unmarshal(response.Body(), &result)
 	if err != nil {
-		return ClientMatchResponse{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+
+	for _, sport := range result.Sports {
+		c.sportIDs[sport.Slug] = sport.ID
+	}
+
+	return nil
+}
+
+// GetMatches follows the provider's pagination until the last page or maxPages, merging every page into one response
+func (c *client) GetMatches(ctx context.Context, gameType GameType) (ClientMatchResponse, error) {
+	sportsID, err := c.getSportsID(ctx, gameType)
+	if err != nil {
+		return ClientMatchResponse{}, err
+	}
+	url := fmt.Sprintf("%s/sports/%d/events", c.apiHost, sportsID)
+
+	var result ClientMatchResponse
+	for page := 1; c.options.MaxPages <= 0 || page <= c.options.MaxPages; page++ {
+		// Stop between pages rather than discovering the cancellation on the next request
+		if err := ctx.Err(); err != nil {
+			return ClientMatchResponse{}, err
+		}
+
+		response, err := c.getMatchesPage(ctx, url, page)
+		if err != nil {
+			return ClientMatchResponse{}, err
+		}
+
+		result.Matches = append(result.Matches, response.Matches...)
+		result.Meta = response.Meta
+		if response.Meta.CurrentPage >= response.Meta.LastPage || len(response.Matches) == 0 {
+			break
+		}
 	}
 
 	return result, nil
 }
 
 func (c *client) GetLiveMatches(ctx context.Context, gameType GameType) (ClientMatchResponse, error) {
-	sportsID := c.getSportsID(gameType)
-	if sportsID == 0 {
-		return ClientMatchResponse{}, fmt.Errorf("invalid game type")
+	sportsID, err := c.getSportsID(ctx, gameType)
+	if err != nil {
+		return ClientMatchResponse{}, err
 	}
-	url := fmt.Sprintf("%s/sports/%d/events/live", c.apiHost, c.getSportsID(gameType))
+	url := fmt.Sprintf("%s/sports/%d/events/live", c.apiHost, sportsID)
 
-	response, err := c.resty.R().
-		SetHeader("x-rapidapi-key", c.apiKey).
-		SetContext(ctx).
-		Get(url)
+	return c.getMatchesPage(ctx, url, 0)
+}
 
+// getMatchesPage fetches a single page of matches, page 0 leaves it up to the provider
+func (c *client) getMatchesPage(ctx context.Context, url string, page int) (ClientMatchResponse, error) {
+	query := map[string]string{}
+	if page > 0 {
+		query["page"] = strconv.Itoa(page)
+	}
+
+	response, err := c.get(ctx, url, query)
 	if err != nil {
 		return ClientMatchResponse{}, err
 	}
@@ -109,3 +212,112 @@ func (c *client) GetLiveMatches(ctx context.Context, gameType GameType) (ClientM
 
 	return result, nil
 }
+
+// get waits for the rate limiter and makes the request, retrying timeouts and server errors with backoff
+func (c *client) get(ctx context.Context, url string, query map[string]string) (*resty.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		response, err := c.resty.R().
+			SetHeader("x-rapidapi-key", c.apiKey).
+			SetQueryParams(query).
+			SetContext(ctx).
+			Get(url)
+		if err == nil {
+			c.updateQuota(response.Header())
+		}
+
+		if attempt >= c.options.MaxRetries || !c.shouldRetry(ctx, response, err) {
+			return response, err
+		}
+
+		delay, ok := c.getRetryDelay(attempt, response)
+		if !ok {
+			return response, err
+		}
+
+		zap.S().Warnw(
+			"Retrying sports API request",
+			zap.String("url", url),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *client) updateQuota(header http.Header) {
+	quota, ok := parseQuota(header, time.Now())
+	if !ok {
+		return
+	}
+
+	c.quotaMutex.Lock()
+	defer c.quotaMutex.Unlock()
+	c.quota = quota
+}
+
+// shouldRetry is true for timeouts, server errors and rate limiting, as long as there's quota left to retry with
+func (c *client) shouldRetry(ctx context.Context, response *resty.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+
+	status := response.StatusCode()
+	if status == http.StatusTooManyRequests {
+		return !c.Quota().IsExhausted(time.Now())
+	}
+
+	return status >= http.StatusInternalServerError
+}
+
+// getRetryDelay prefers the provider's Retry-After and otherwise backs off exponentially with jitter,
+// ok is false when the provider asks us to wait longer than RetryMaxDelay
+func (c *client) getRetryDelay(attempt int, response *resty.Response) (time.Duration, bool) {
+	if response != nil {
+		if delay, ok := parseRetryAfter(response.Header().Get("Retry-After"), time.Now()); ok {
+			return delay, delay <= c.options.RetryMaxDelay
+		}
+	}
+
+	delay := c.options.RetryBaseDelay << attempt
+	if delay <= 0 || delay > c.options.RetryMaxDelay {
+		delay = c.options.RetryMaxDelay
+	}
+
+	// Equal jitter keeps retries from lining up while still waiting at least half the backoff
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)), true
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
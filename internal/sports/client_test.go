@@ -0,0 +1,499 @@
+package sports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// newPagedProvider stands in for the scores API, serving lastPage pages of perPage tennis matches numbered from 1.
+// The pages it was asked for are recorded in requested
+func newPagedProvider(t *testing.T, lastPage int, perPage int) (*httptest.Server, func() []int) {
+	t.Helper()
+
+	mutex := &sync.Mutex{}
+	var requested []int
+	provider := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/sports/2/events" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				if r.Header.Get("x-rapidapi-key") != "key" {
+					t.Errorf("x-rapidapi-key = %q, want key", r.Header.Get("x-rapidapi-key"))
+				}
+				page, err := strconv.Atoi(r.URL.Query().Get("page"))
+				if err != nil {
+					t.Errorf("page %q isn't a number", r.URL.Query().Get("page"))
+				}
+
+				mutex.Lock()
+				requested = append(requested, page)
+				mutex.Unlock()
+
+				response := ClientMatchResponse{
+					Meta: ClientMeta{CurrentPage: page, LastPage: lastPage, PerPage: perPage},
+				}
+				if page <= lastPage {
+					for i := 1; i <= perPage; i++ {
+						response.Matches = append(response.Matches, ClientMatch{ID: (page-1)*perPage + i})
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(response)
+			},
+		),
+	)
+	t.Cleanup(provider.Close)
+
+	return provider, func() []int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return append([]int(nil), requested...)
+	}
+}
+
+func getMatchIDs(response ClientMatchResponse) []int {
+	ids := make([]int, 0, len(response.Matches))
+	for _, match := range response.Matches {
+		ids = append(ids, match.ID)
+	}
+
+	return ids
+}
+
+func TestClientGetMatchesPagination(t *testing.T) {
+	tests := []struct {
+		name          string
+		lastPage      int
+		maxPages      int
+		wantRequested []int
+		wantIDs       []int
+	}{
+		{name: "single page", lastPage: 1, wantRequested: []int{1}, wantIDs: []int{1, 2}},
+		{name: "every page", lastPage: 3, wantRequested: []int{1, 2, 3}, wantIDs: []int{1, 2, 3, 4, 5, 6}},
+		{
+			name:          "max pages",
+			lastPage:      5,
+			maxPages:      2,
+			wantRequested: []int{1, 2},
+			wantIDs:       []int{1, 2, 3, 4},
+		},
+		{
+			name:          "max pages past the last page",
+			lastPage:      2,
+			maxPages:      10,
+			wantRequested: []int{1, 2},
+			wantIDs:       []int{1, 2, 3, 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				provider, requested := newPagedProvider(t, test.lastPage, 2)
+				client, err := NewClient(resty.New(), provider.URL, "key", ClientOptions{MaxPages: test.maxPages})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				response, err := client.GetMatches(context.Background(), Tennis)
+				if err != nil {
+					t.Fatalf("GetMatches() error = %v", err)
+				}
+				if got := requested(); !slices.Equal(got, test.wantRequested) {
+					t.Errorf("requested pages %v, want %v", got, test.wantRequested)
+				}
+				if got := getMatchIDs(response); !slices.Equal(got, test.wantIDs) {
+					t.Errorf("GetMatches() ids = %v, want %v", got, test.wantIDs)
+				}
+			},
+		)
+	}
+}
+
+func TestClientGetMatchesCancelledBetweenPages(t *testing.T) {
+	provider, requested := newPagedProvider(t, 3, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancelling once the first page is in lets the request finish, so only the check between pages can stop it
+	restyClient := resty.New().OnAfterResponse(
+		func(_ *resty.Client, response *resty.Response) error {
+			if response.Request.QueryParam.Get("page") == "1" {
+				cancel()
+			}
+			return nil
+		},
+	)
+	client, err := NewClient(restyClient, provider.URL, "key", ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetMatches(ctx, Tennis)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetMatches() error = %v, want %v", err, context.Canceled)
+	}
+	if got := requested(); !slices.Equal(got, []int{1}) {
+		t.Errorf("requested pages %v, want [1]", got)
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ClientOptions
+		wantErr bool
+	}{
+		{name: "no retries", options: ClientOptions{}},
+		{
+			name:    "retries",
+			options: ClientOptions{MaxRetries: 3, RetryBaseDelay: time.Second, RetryMaxDelay: 10 * time.Second},
+		},
+		{name: "negative retries", options: ClientOptions{MaxRetries: -1}, wantErr: true},
+		{name: "negative delay", options: ClientOptions{RetryBaseDelay: -time.Second}, wantErr: true},
+		{
+			name:    "max delay under the base delay",
+			options: ClientOptions{MaxRetries: 3, RetryBaseDelay: 10 * time.Second, RetryMaxDelay: time.Second},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				_, err := NewClient(resty.New(), "https://scores.example.com", "key", test.options)
+				if (err != nil) != test.wantErr {
+					t.Errorf("NewClient() error = %v, want error %t", err, test.wantErr)
+				}
+			},
+		)
+	}
+}
+
+// testResponse is one of the responses a provider stand-in gives in turn
+type testResponse struct {
+	status int
+	header http.Header
+	// delay holds the response back, so requests made with a shorter timeout time out
+	delay time.Duration
+}
+
+// newSequenceProvider stands in for the scores API, answering live match requests with responses in turn and
+// repeating the last one. It returns how many requests it was sent
+func newSequenceProvider(t *testing.T, responses []testResponse) (*httptest.Server, func() int) {
+	t.Helper()
+
+	mutex := &sync.Mutex{}
+	requests := 0
+	provider := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				response := responses[min(requests, len(responses)-1)]
+				requests++
+				mutex.Unlock()
+
+				select {
+				case <-time.After(response.delay):
+				case <-r.Context().Done():
+					return
+				}
+				for key, values := range response.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(response.status)
+				if response.status == http.StatusOK {
+					_ = json.NewEncoder(w).Encode(ClientMatchResponse{Matches: []ClientMatch{{ID: 1}}})
+				}
+			},
+		),
+	)
+	t.Cleanup(provider.Close)
+
+	return provider, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return requests
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	ok := testResponse{status: http.StatusOK}
+	unavailable := testResponse{status: http.StatusServiceUnavailable}
+	exhausted := http.Header{
+		"X-Ratelimit-Requests-Remaining": {"0"},
+		"X-Ratelimit-Requests-Reset":     {"3600"},
+	}
+
+	tests := []struct {
+		name         string
+		responses    []testResponse
+		maxRetries   int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "no errors", responses: []testResponse{ok}, maxRetries: 3, wantRequests: 1},
+		{
+			name:         "server errors",
+			responses:    []testResponse{{status: http.StatusInternalServerError}, unavailable, ok},
+			maxRetries:   3,
+			wantRequests: 3,
+		},
+		{name: "out of retries", responses: []testResponse{unavailable}, maxRetries: 2, wantRequests: 3, wantErr: true},
+		{name: "retries turned off", responses: []testResponse{unavailable, ok}, wantRequests: 1, wantErr: true},
+		{
+			name:         "client errors",
+			responses:    []testResponse{{status: http.StatusNotFound}, ok},
+			maxRetries:   3,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "timeouts",
+			responses:    []testResponse{{status: http.StatusOK, delay: time.Second}, ok},
+			maxRetries:   3,
+			wantRequests: 2,
+		},
+		{
+			name:         "rate limited",
+			responses:    []testResponse{{status: http.StatusTooManyRequests}, ok},
+			maxRetries:   3,
+			wantRequests: 2,
+		},
+		{
+			name:         "rate limited with no quota left",
+			responses:    []testResponse{{status: http.StatusTooManyRequests, header: exhausted}, ok},
+			maxRetries:   3,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name: "asked to retry soon",
+			responses: []testResponse{
+				{status: http.StatusServiceUnavailable, header: http.Header{"Retry-After": {"0"}}},
+				ok,
+			},
+			maxRetries:   3,
+			wantRequests: 2,
+		},
+		{
+			name: "asked to retry later than the max delay",
+			responses: []testResponse{
+				{status: http.StatusServiceUnavailable, header: http.Header{"Retry-After": {"120"}}},
+				ok,
+			},
+			maxRetries:   3,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				provider, requests := newSequenceProvider(t, test.responses)
+				client, err := NewClient(
+					resty.New().SetTimeout(100*time.Millisecond),
+					provider.URL,
+					"key",
+					ClientOptions{
+						MaxRetries:     test.maxRetries,
+						RetryBaseDelay: time.Millisecond,
+						RetryMaxDelay:  10 * time.Millisecond,
+					},
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				response, err := client.GetLiveMatches(context.Background(), Tennis)
+				if (err != nil) != test.wantErr {
+					t.Fatalf("GetLiveMatches() error = %v, want error %t", err, test.wantErr)
+				}
+				if !test.wantErr && !slices.Equal(getMatchIDs(response), []int{1}) {
+					t.Errorf("GetLiveMatches() ids = %v, want [1]", getMatchIDs(response))
+				}
+				if got := requests(); got != test.wantRequests {
+					t.Errorf("sent %d requests, want %d", got, test.wantRequests)
+				}
+			},
+		)
+	}
+}
+
+func TestClientGetRetryDelay(t *testing.T) {
+	now := time.Now()
+	c := &client{options: ClientOptions{RetryBaseDelay: 100 * time.Millisecond, RetryMaxDelay: 5 * time.Second}}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		// The delay is jittered between wantMin and wantMax
+		wantMin time.Duration
+		wantMax time.Duration
+		wantOK  bool
+	}{
+		{name: "first", attempt: 0, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond, wantOK: true},
+		{name: "backoff", attempt: 3, wantMin: 400 * time.Millisecond, wantMax: 800 * time.Millisecond, wantOK: true},
+		{name: "capped", attempt: 10, wantMin: 2500 * time.Millisecond, wantMax: 5 * time.Second, wantOK: true},
+		{name: "overflowing", attempt: 62, wantMin: 2500 * time.Millisecond, wantMax: 5 * time.Second, wantOK: true},
+		{name: "retry after", retryAfter: "2", wantMin: 2 * time.Second, wantMax: 2 * time.Second, wantOK: true},
+		{
+			name:       "retry after a date",
+			retryAfter: now.Add(3 * time.Second).UTC().Format(http.TimeFormat),
+			wantMin:    time.Second,
+			wantMax:    3 * time.Second,
+			wantOK:     true,
+		},
+		{name: "retry after a date that's passed", retryAfter: "Mon, 01 Jul 2024 12:00:00 GMT", wantOK: true},
+		{name: "retry after the max delay", retryAfter: "60", wantMin: time.Minute, wantMax: time.Minute},
+		{
+			name:       "unreadable retry after",
+			retryAfter: "soon",
+			wantMin:    50 * time.Millisecond,
+			wantMax:    100 * time.Millisecond,
+			wantOK:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				header := http.Header{}
+				if test.retryAfter != "" {
+					header.Set("Retry-After", test.retryAfter)
+				}
+				response := &resty.Response{RawResponse: &http.Response{Header: header}}
+
+				delay, ok := c.getRetryDelay(test.attempt, response)
+				if ok != test.wantOK {
+					t.Errorf("getRetryDelay() ok = %t, want %t", ok, test.wantOK)
+				}
+				if delay < test.wantMin || delay > test.wantMax {
+					t.Errorf("getRetryDelay() = %s, want between %s and %s", delay, test.wantMin, test.wantMax)
+				}
+			},
+		)
+	}
+}
+
+func TestClientQuota(t *testing.T) {
+	provider, _ := newSequenceProvider(
+		t,
+		[]testResponse{
+			{
+				status: http.StatusOK,
+				header: http.Header{
+					"X-Ratelimit-Requests-Limit":     {"500"},
+					"X-Ratelimit-Requests-Remaining": {"42"},
+					"X-Ratelimit-Requests-Reset":     {"60"},
+				},
+			},
+			// Responses without the headers leave what's known about the quota as it was
+			{status: http.StatusOK},
+		},
+	)
+	client, err := NewClient(resty.New(), provider.URL, "key", ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quota := client.Quota(); quota.Known {
+		t.Errorf("Quota() = %+v before any request", quota)
+	}
+	for i := 0; i < 2; i++ {
+		before := time.Now()
+		if _, err := client.GetLiveMatches(context.Background(), Tennis); err != nil {
+			t.Fatal(err)
+		}
+
+		quota := client.Quota()
+		if !quota.Known || quota.Limit != 500 || quota.Remaining != 42 {
+			t.Errorf("Quota() = %+v after request %d", quota, i+1)
+		}
+		resetAfter, resetBefore := before.Add(time.Minute), time.Now().Add(time.Minute)
+		if i == 0 && (quota.ResetAt.Before(resetAfter) || quota.ResetAt.After(resetBefore)) {
+			t.Errorf("quota resets at %s, want a minute after the request", quota.ResetAt)
+		}
+	}
+}
+
+func TestParseQuota(t *testing.T) {
+	now := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		header        http.Header
+		want          Quota
+		wantOK        bool
+		wantLow       bool
+		wantExhausted bool
+	}{
+		{name: "no headers", header: http.Header{}},
+		{
+			name: "plenty left",
+			header: http.Header{
+				"X-Ratelimit-Requests-Limit":     {"500"},
+				"X-Ratelimit-Requests-Remaining": {"400"},
+				"X-Ratelimit-Requests-Reset":     {"3600"},
+			},
+			want:   Quota{Known: true, Limit: 500, Remaining: 400, ResetAt: now.Add(time.Hour), UpdatedAt: now},
+			wantOK: true,
+		},
+		{
+			name:    "running low",
+			header:  http.Header{"X-Ratelimit-Requests-Remaining": {"10"}},
+			want:    Quota{Known: true, Remaining: 10, UpdatedAt: now},
+			wantOK:  true,
+			wantLow: true,
+		},
+		{
+			name: "none left until a reset",
+			header: http.Header{
+				"X-Ratelimit-Requests-Remaining": {"0"},
+				"X-Ratelimit-Requests-Reset":     {"60"},
+			},
+			want:          Quota{Known: true, ResetAt: now.Add(time.Minute), UpdatedAt: now},
+			wantOK:        true,
+			wantLow:       true,
+			wantExhausted: true,
+		},
+		{
+			name:    "none left without a reset",
+			header:  http.Header{"X-Ratelimit-Requests-Remaining": {"0"}},
+			want:    Quota{Known: true, UpdatedAt: now},
+			wantOK:  true,
+			wantLow: true,
+		},
+		{name: "unreadable", header: http.Header{"X-Ratelimit-Requests-Remaining": {"lots"}}},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				quota, ok := parseQuota(test.header, now)
+				if ok != test.wantOK || quota != test.want {
+					t.Fatalf("parseQuota() = %+v, %t, want %+v, %t", quota, ok, test.want, test.wantOK)
+				}
+				if low := quota.IsLow(50); low != test.wantLow {
+					t.Errorf("IsLow(50) = %t, want %t", low, test.wantLow)
+				}
+				if exhausted := quota.IsExhausted(now); exhausted != test.wantExhausted {
+					t.Errorf("IsExhausted() = %t, want %t", exhausted, test.wantExhausted)
+				}
+				if quota.IsExhausted(now.Add(2 * time.Minute)) {
+					t.Error("IsExhausted() after the quota reset")
+				}
+			},
+		)
+	}
+}
@@ -7,6 +7,15 @@ import (
 
 type ClientMatchResponse struct {
 	Matches []ClientMatch `json:"data"`
+	Meta    ClientMeta    `json:"meta"`
+}
+
+// ClientMeta describes which page of a paginated response was returned
+type ClientMeta struct {
+	CurrentPage int `json:"current_page"`
+	LastPage    int `json:"last_page"`
+	PerPage     int `json:"per_page"`
+	Total       int `json:"total"`
 }
 
 type ClientMatch struct {
@@ -1,11 +1,37 @@
 package sports
 
+import "time"
+
+// The provider's statuses of matches being played and of matches that are over, though many drop out of the live
+// matches without ever being given MatchStatusFinished
+const (
+	MatchStatusInProgress = "inprogress"
+	MatchStatusFinished   = "finished"
+)
+
 type Team struct {
 	Name string
+	// ShortName and Code are shorter names to fall back to when Name doesn't fit, either can be empty
+	ShortName string
+	Code      string
+	// Logo and Flag are URLs of images of the team and of the country it plays for, either can be empty
+	Logo string
+	Flag string
+	// CountryCode is the ISO 3166 code of the team's country as the provider reports it, e.g. "US" or "ENG"
+	CountryCode string
 }
 type Match struct {
-	GameType GameType
-	Home     Team
-	Away     Team
-	Score    Score
+	// ID is the provider's id for the match, it's stable across updates
+	ID         int
+	GameType   GameType
+	Home       Team
+	Away       Team
+	Score      Score
+	League     string
+	Season     string
+	Status     string
+	StatusMore string
+	StartAt    time.Time
+	// CurrentPeriodStartAt is zero when the provider doesn't time periods for the sport
+	CurrentPeriodStartAt time.Time
 }
@@ -0,0 +1,79 @@
+package sports
+
+import (
+	"reflect"
+	"time"
+)
+
+// EventType is what happened to a live match between two updates
+type EventType string
+
+const (
+	EventMatchAdded    EventType = "match_added"
+	EventMatchStarted  EventType = "match_started"
+	EventScoreChanged  EventType = "score_changed"
+	EventPeriodChanged EventType = "period_changed"
+	EventMatchFinished EventType = "match_finished"
+)
+
+// Event is a change to a live match. Match is as it was last seen, which for matches that finished by dropping out of
+// the live matches is as they were in the update before. Previous is the match as of the update before, it's nil for
+// matches that weren't in it
+type Event struct {
+	Type     EventType
+	Match    Match
+	Previous *Match
+	At       time.Time
+}
+
+// getMatchEvents compares two updates of a sport's live matches by match id. Matches start when they're first seen
+// in progress, and finish either by the provider saying so or by dropping out of the live matches, whichever comes
+// first. Periods change with the provider's more detailed status, which names the period being played
+func getMatchEvents(previous []Match, current []Match, at time.Time) []Event {
+	previousByID := make(map[int]Match, len(previous))
+	for _, match := range previous {
+		previousByID[match.ID] = match
+	}
+
+	var events []Event
+	currentIDs := make(map[int]bool, len(current))
+	for _, match := range current {
+		currentIDs[match.ID] = true
+
+		last, ok := previousByID[match.ID]
+		var lastPtr *Match
+		if ok {
+			lastPtr = &last
+		}
+		newEvent := func(eventType EventType) Event {
+			return Event{Type: eventType, Match: match, Previous: lastPtr, At: at}
+		}
+
+		if !ok {
+			events = append(events, newEvent(EventMatchAdded))
+		}
+		started := match.Status == MatchStatusInProgress && (!ok || last.Status != MatchStatusInProgress)
+		if started {
+			events = append(events, newEvent(EventMatchStarted))
+		}
+		if ok && !reflect.DeepEqual(last.Score, match.Score) {
+			events = append(events, newEvent(EventScoreChanged))
+		}
+		// Starting moves matches into their first period, which doesn't need telling apart
+		if ok && !started && match.Status == MatchStatusInProgress && last.StatusMore != match.StatusMore {
+			events = append(events, newEvent(EventPeriodChanged))
+		}
+		if match.Status == MatchStatusFinished && (!ok || last.Status != MatchStatusFinished) {
+			events = append(events, newEvent(EventMatchFinished))
+		}
+	}
+
+	for _, match := range previous {
+		if !currentIDs[match.ID] && match.Status != MatchStatusFinished {
+			last := match
+			events = append(events, Event{Type: EventMatchFinished, Match: match, Previous: &last, At: at})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,141 @@
+package sports
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testEvent is what's compared of an event, the match it's about and whether it came with the match before
+type testEvent struct {
+	eventType   EventType
+	id          int
+	hasPrevious bool
+}
+
+func TestGetMatchEvents(t *testing.T) {
+	at := time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+	notStarted := Match{ID: 1, GameType: Basketball, Status: "notstarted"}
+	playing := Match{
+		ID:         1,
+		GameType:   Basketball,
+		Status:     MatchStatusInProgress,
+		StatusMore: "1st quarter",
+		Score:      Score{Home: []string{"10"}, HomeTotal: "10", Away: []string{"8"}, AwayTotal: "8"},
+	}
+	scored := playing
+	scored.Score = Score{Home: []string{"12"}, HomeTotal: "12", Away: []string{"8"}, AwayTotal: "8"}
+	nextPeriod := playing
+	nextPeriod.StatusMore = "2nd quarter"
+	scoredNextPeriod := scored
+	scoredNextPeriod.StatusMore = "2nd quarter"
+	finished := playing
+	finished.Status = MatchStatusFinished
+	other := Match{ID: 2, GameType: Tennis, Status: MatchStatusInProgress}
+
+	tests := []struct {
+		name     string
+		previous []Match
+		current  []Match
+		want     []testEvent
+	}{
+		{name: "no change", previous: []Match{playing, other}, current: []Match{playing, other}},
+		{name: "no matches", previous: []Match{}, current: []Match{}},
+		{
+			name:     "score changed",
+			previous: []Match{playing, other},
+			current:  []Match{scored, other},
+			want:     []testEvent{{eventType: EventScoreChanged, id: 1, hasPrevious: true}},
+		},
+		{
+			name:     "period changed",
+			previous: []Match{playing},
+			current:  []Match{nextPeriod},
+			want:     []testEvent{{eventType: EventPeriodChanged, id: 1, hasPrevious: true}},
+		},
+		{
+			name:     "period and score changed",
+			previous: []Match{playing},
+			current:  []Match{scoredNextPeriod},
+			want: []testEvent{
+				{eventType: EventScoreChanged, id: 1, hasPrevious: true},
+				{eventType: EventPeriodChanged, id: 1, hasPrevious: true},
+			},
+		},
+		{
+			name:     "started",
+			previous: []Match{notStarted},
+			current:  []Match{playing},
+			want: []testEvent{
+				{eventType: EventMatchStarted, id: 1, hasPrevious: true},
+				{eventType: EventScoreChanged, id: 1, hasPrevious: true},
+			},
+		},
+		{
+			name:     "finished by the provider",
+			previous: []Match{playing},
+			current:  []Match{finished},
+			want:     []testEvent{{eventType: EventMatchFinished, id: 1, hasPrevious: true}},
+		},
+		{name: "still finished", previous: []Match{finished}, current: []Match{finished}},
+		{
+			name:     "added",
+			previous: []Match{other},
+			current:  []Match{other, notStarted},
+			want:     []testEvent{{eventType: EventMatchAdded, id: 1}},
+		},
+		{
+			name:     "added while being played",
+			previous: []Match{other},
+			current:  []Match{other, playing},
+			want:     []testEvent{{eventType: EventMatchAdded, id: 1}, {eventType: EventMatchStarted, id: 1}},
+		},
+		{
+			name:     "added already finished",
+			previous: []Match{},
+			current:  []Match{finished},
+			want:     []testEvent{{eventType: EventMatchAdded, id: 1}, {eventType: EventMatchFinished, id: 1}},
+		},
+		{
+			name:     "left the live matches",
+			previous: []Match{playing, other},
+			current:  []Match{other},
+			want:     []testEvent{{eventType: EventMatchFinished, id: 1, hasPrevious: true}},
+		},
+		{name: "left the live matches after finishing", previous: []Match{finished, other}, current: []Match{other}},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				events := getMatchEvents(test.previous, test.current, at)
+
+				var got []testEvent
+				for _, event := range events {
+					got = append(
+						got,
+						testEvent{eventType: event.Type, id: event.Match.ID, hasPrevious: event.Previous != nil},
+					)
+					if !event.At.Equal(at) {
+						t.Errorf("%s event at %s, want %s", event.Type, event.At, at)
+					}
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Errorf("getMatchEvents() = %+v, want %+v", got, test.want)
+				}
+			},
+		)
+	}
+}
+
+// TestGetMatchEventsLeftLiveMatches checks matches that finish by leaving the live matches are told as last seen
+func TestGetMatchEventsLeftLiveMatches(t *testing.T) {
+	playing := Match{ID: 1, GameType: Basketball, Status: MatchStatusInProgress, StatusMore: "4th quarter"}
+
+	events := getMatchEvents([]Match{playing}, nil, time.Now())
+	if len(events) != 1 {
+		t.Fatalf("getMatchEvents() = %+v, want a single event", events)
+	}
+	if !reflect.DeepEqual(events[0].Match, playing) || !reflect.DeepEqual(events[0].Previous, &playing) {
+		t.Errorf("finished match = %+v, previous %+v, want both as last seen", events[0].Match, events[0].Previous)
+	}
+}
@@ -8,4 +8,8 @@ const (
 	Unknown GameType = iota
 	Basketball
 	Tennis
+	Soccer
+	IceHockey
+	AmericanFootball
+	Baseball
 )
@@ -11,15 +11,20 @@ func _() {
 	_ = x[Unknown-0]
 	_ = x[Basketball-1]
 	_ = x[Tennis-2]
+	_ = x[Soccer-3]
+	_ = x[IceHockey-4]
+	_ = x[AmericanFootball-5]
+	_ = x[Baseball-6]
 }
 
-const _GameType_name = "UnknownBasketballTennis"
+const _GameType_name = "UnknownBasketballTennisSoccerIceHockeyAmericanFootballBaseball"
 
-var _GameType_index = [...]uint8{0, 7, 17, 23}
+var _GameType_index = [...]uint8{0, 7, 17, 23, 29, 38, 54, 62}
 
 func (i GameType) String() string {
-	if i < 0 || i >= GameType(len(_GameType_index)-1) {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_GameType_index)-1 {
 		return "GameType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _GameType_name[_GameType_index[i]:_GameType_index[i+1]]
+	return _GameType_name[_GameType_index[idx]:_GameType_index[idx+1]]
 }
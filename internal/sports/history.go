@@ -0,0 +1,35 @@
+package sports
+
+import (
+	"context"
+	"time"
+)
+
+// HistoryRepository records every match seen by updates, so matches outlive both restarts and the live feed
+type HistoryRepository interface {
+	// SaveMatches records the matches of an update of a sport. Live updates also finish the matches that have
+	// dropped out of the live feed since the last one
+	SaveMatches(ctx context.Context, gameType GameType, live bool, matches []Match, seenAt time.Time) error
+	// GetResults returns matches that finished between from and to, the latest first
+	GetResults(ctx context.Context, from time.Time, to time.Time) ([]Result, error)
+	// GetTeamResults returns the last limit matches a team finished, the team is matched against the name, short name
+	// and code of either side ignoring case
+	GetTeamResults(ctx context.Context, team string, limit int) ([]Result, error)
+	// GetTimeline returns a match's score as it was every time it changed, the earliest first. It returns
+	// ErrMatchNotFound for matches that were never seen
+	GetTimeline(ctx context.Context, id int) (Match, []Snapshot, error)
+}
+
+// Result is a match as it was last seen before it finished
+type Result struct {
+	Match      Match
+	FinishedAt time.Time
+}
+
+// Snapshot is how a match stood in an update
+type Snapshot struct {
+	SeenAt     time.Time
+	Status     string
+	StatusMore string
+	Score      Score
+}
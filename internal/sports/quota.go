@@ -0,0 +1,55 @@
+package sports
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RapidAPI reports how much of the plan's quota is left on every response
+const (
+	headerRateLimitLimit     = "x-ratelimit-requests-limit"
+	headerRateLimitRemaining = "x-ratelimit-requests-remaining"
+	headerRateLimitReset     = "x-ratelimit-requests-reset"
+)
+
+// Quota is what the provider last told us about our request quota
+type Quota struct {
+	Known     bool
+	Limit     int
+	Remaining int
+	// ResetAt is when the quota is replenished, zero when the provider didn't say
+	ResetAt   time.Time
+	UpdatedAt time.Time
+}
+
+// IsLow is true once fewer than watermark requests are left
+func (q Quota) IsLow(watermark int) bool {
+	return q.Known && q.Remaining < watermark
+}
+
+// IsExhausted is true when there are no requests left until a known reset. Without a reset time there's no telling
+// when requests will work again, so the quota only counts as low
+func (q Quota) IsExhausted(now time.Time) bool {
+	if !q.Known || q.Remaining > 0 || q.ResetAt.IsZero() {
+		return false
+	}
+
+	return now.Before(q.ResetAt)
+}
+
+// parseQuota reads the rate limit headers of a response, ok is false when there are none
+func parseQuota(header http.Header, now time.Time) (Quota, bool) {
+	remaining, err := strconv.Atoi(header.Get(headerRateLimitRemaining))
+	if err != nil {
+		return Quota{}, false
+	}
+
+	quota := Quota{Known: true, Remaining: remaining, UpdatedAt: now}
+	quota.Limit, _ = strconv.Atoi(header.Get(headerRateLimitLimit))
+	if reset, err := strconv.Atoi(header.Get(headerRateLimitReset)); err == nil {
+		quota.ResetAt = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	return quota, true
+}
@@ -0,0 +1,97 @@
+package sports
+
+// Sport describes everything that differs between the sports we show scores for
+type Sport struct {
+	GameType GameType
+	// Slug identifies the sport in our own urls
+	Slug string
+	// ProviderID is the sport's id in the scores API, when it's zero the id is looked up by ProviderSlug
+	ProviderID   int
+	ProviderSlug string
+	ScoringFunc  ScoringFunc
+	DisplayName  string
+	Emoji        string
+}
+
+// registry is ordered the way sports are offered to users
+var registry = []Sport{
+	{
+		GameType:     Tennis,
+		Slug:         "tennis",
+		ProviderID:   2,
+		ProviderSlug: "tennis",
+		ScoringFunc:  FormatTennisScore,
+		DisplayName:  "Tennis",
+		Emoji:        "🎾",
+	},
+	{
+		GameType:     Basketball,
+		Slug:         "basketball",
+		ProviderID:   3,
+		ProviderSlug: "basketball",
+		ScoringFunc:  FormatBasketballScore,
+		DisplayName:  "Basketball",
+		Emoji:        "🏀",
+	},
+	{
+		GameType:     Soccer,
+		Slug:         "soccer",
+		ProviderID:   1,
+		ProviderSlug: "football",
+		ScoringFunc:  FormatSoccerScore,
+		DisplayName:  "Soccer",
+		Emoji:        "⚽",
+	},
+	{
+		GameType:     IceHockey,
+		Slug:         "ice-hockey",
+		ProviderID:   4,
+		ProviderSlug: "ice-hockey",
+		ScoringFunc:  FormatIceHockeyScore,
+		DisplayName:  "Ice Hockey",
+		Emoji:        "🏒",
+	},
+	{
+		GameType:     AmericanFootball,
+		Slug:         "american-football",
+		ProviderSlug: "american-football",
+		ScoringFunc:  FormatAmericanFootballScore,
+		DisplayName:  "American Football",
+		Emoji:        "🏈",
+	},
+	{
+		GameType:     Baseball,
+		Slug:         "baseball",
+		ProviderSlug: "baseball",
+		ScoringFunc:  FormatBaseballScore,
+		DisplayName:  "Baseball",
+		Emoji:        "⚾",
+	},
+}
+
+// Sports returns every supported sport
+func Sports() []Sport {
+	sports := make([]Sport, len(registry))
+	copy(sports, registry)
+	return sports
+}
+
+func GetSport(gameType GameType) (Sport, bool) {
+	for _, sport := range registry {
+		if sport.GameType == gameType {
+			return sport, true
+		}
+	}
+
+	return Sport{}, false
+}
+
+func GetSportBySlug(slug string) (Sport, bool) {
+	for _, sport := range registry {
+		if sport.Slug == slug {
+			return sport, true
+		}
+	}
+
+	return Sport{}, false
+}
@@ -3,34 +3,135 @@ package sports
 import (
 	"fmt"
 	"strconv"
-	"strings"
 )
 
 type ScoringFunc func(ClientMatch) (Score, error)
 
 type Score struct {
+	// Periods labels the columns of Home and Away, it's empty when periods are simply numbered
+	Periods   []string
 	Home      []string
 	HomeTotal string
 	Away      []string
 	AwayTotal string
+	// Tennis is only set for tennis matches, Home and Away hold the games of each set
+	Tennis *TennisScore
 }
 
+// GetPeriodLabels returns the labels of a score's periods, numbering them when the sport doesn't label them
+func (s Score) GetPeriodLabels() []string {
+	if len(s.Periods) == len(s.Home) {
+		return append([]string(nil), s.Periods...)
+	}
+
+	labels := make([]string, 0, len(s.Home))
+	for i := range s.Home {
+		labels = append(labels, strconv.Itoa(i+1))
+	}
+
+	return labels
+}
+
+// Basketball is played in four quarters unless the provider says otherwise
+const basketballQuarters = 4
+
+// FormatBasketballScore shows points per quarter followed by each overtime and the running totals. Depending on the
+// league the provider reports overtimes as further numbered periods, as overtime_N, or as a single overtime total.
 func FormatBasketballScore(match ClientMatch) (Score, error) {
-	lp := strings.TrimPrefix(match.LastedPeriod, "period_")
-	lastPeriod, err := strconv.Atoi(lp)
-	if err != nil {
-		return Score{}, fmt.Errorf("unable to determine last period: %w", err)
+	regulation := match.DefaultPeriodCount
+	if regulation == 0 {
+		regulation = basketballQuarters
+	}
+
+	var extras []extraPeriod
+	if countPeriods(match.HomeScore) <= regulation {
+		extras = getOvertimePeriods(match.HomeScore)
 	}
 
+	return formatPeriodScore(
+		match,
+		func(period int) string {
+			if period <= regulation {
+				return fmt.Sprintf("Q%d", period)
+			}
+			return getOvertimeLabel(period - regulation)
+		},
+		extras...,
+	)
+}
+
+// extraPeriod is a period the provider reports under its own key, such as overtime or a shootout
+type extraPeriod struct {
+	key   string
+	label string
+}
+
+// Provider keys for periods played after regulation and for the running total
+const (
+	overtimeKey  = "overtime"
+	penaltiesKey = "penalties"
+	displayKey   = "display"
+	currentKey   = "current"
+)
+
+// FormatSoccerScore shows goals per half, then extra time and penalties when they're played
+func FormatSoccerScore(match ClientMatch) (Score, error) {
+	return formatPeriodScore(
+		match,
+		func(period int) string { return fmt.Sprintf("%dH", period) },
+		extraPeriod{key: overtimeKey, label: "ET"},
+		extraPeriod{key: penaltiesKey, label: "PEN"},
+	)
+}
+
+// FormatIceHockeyScore shows goals per period, then overtime and the shootout when they're played
+func FormatIceHockeyScore(match ClientMatch) (Score, error) {
+	return formatPeriodScore(
+		match,
+		func(period int) string { return fmt.Sprintf("P%d", period) },
+		extraPeriod{key: overtimeKey, label: "OT"},
+		extraPeriod{key: penaltiesKey, label: "SO"},
+	)
+}
+
+// FormatAmericanFootballScore shows points per quarter, then overtime when it's played
+func FormatAmericanFootballScore(match ClientMatch) (Score, error) {
+	return formatPeriodScore(
+		match,
+		func(period int) string { return fmt.Sprintf("Q%d", period) },
+		extraPeriod{key: overtimeKey, label: "OT"},
+	)
+}
+
+// FormatBaseballScore shows runs per inning, extra innings are reported as further periods
+func FormatBaseballScore(match ClientMatch) (Score, error) {
+	return formatPeriodScore(match, strconv.Itoa)
+}
+
+// formatPeriodScore reads every numbered period the provider has a score for, followed by any extra periods that
+// were played, and the running totals
+func formatPeriodScore(match ClientMatch, label func(period int) string, extras ...extraPeriod) (Score, error) {
+	periodCount := countPeriods(match.HomeScore)
 	score := Score{
-		Home: make([]string, 0, lastPeriod),
-		Away: make([]string, 0, lastPeriod),
+		Periods:   make([]string, 0, periodCount+len(extras)),
+		Home:      make([]string, 0, periodCount+len(extras)),
+		Away:      make([]string, 0, periodCount+len(extras)),
+		HomeTotal: getTotal(match.HomeScore),
+		AwayTotal: getTotal(match.AwayScore),
 	}
 
-	// TODO:: Needs a fix for overtime
-	periodKeys := getPeriodKeys(lastPeriod)
+	periods := getPeriodKeys(periodCount)
+	for i := range periods {
+		score.Periods = append(score.Periods, label(i+1))
+	}
+	for _, extra := range extras {
+		if _, ok := match.HomeScore[extra.key]; ok {
+			periods = append(periods, extra.key)
+			score.Periods = append(score.Periods, extra.label)
+		}
+	}
 
-	for _, period := range periodKeys {
+	for _, period := range periods {
 		homeScore, err := getScoreForPeriod(match.HomeScore, period)
 		if err != nil {
 			return Score{}, fmt.Errorf("unable to get home score for period %s: %w", period, err)
@@ -47,35 +148,55 @@ func FormatBasketballScore(match ClientMatch) (Score, error) {
 	return score, nil
 }
 
-func FormatTennisScore(match ClientMatch) (Score, error) {
-	lp := strings.TrimPrefix(match.LastedPeriod, "period_")
-	lastPeriod, err := strconv.Atoi(lp)
-	if err != nil {
-		return Score{}, fmt.Errorf("unable to determine last period: %w", err)
+// getOvertimePeriods returns each numbered overtime the provider has a score for, falling back to the combined
+// overtime score
+func getOvertimePeriods(score ClientScore) []extraPeriod {
+	var overtimes []extraPeriod
+	for i := 1; ; i++ {
+		key := fmt.Sprintf("%s_%d", overtimeKey, i)
+		if _, ok := score[key]; !ok {
+			break
+		}
+		overtimes = append(overtimes, extraPeriod{key: key, label: getOvertimeLabel(i)})
 	}
 
-	score := Score{
-		Home: make([]string, 0, lastPeriod),
-		Away: make([]string, 0, lastPeriod),
+	if len(overtimes) == 0 {
+		overtimes = append(overtimes, extraPeriod{key: overtimeKey, label: getOvertimeLabel(1)})
 	}
 
-	periodKeys := getPeriodKeys(lastPeriod)
+	return overtimes
+}
 
-	for _, period := range periodKeys {
-		homeScore, err := getScoreForPeriod(match.HomeScore, period)
-		if err != nil {
-			return Score{}, fmt.Errorf("unable to get home score for period %s: %w", period, err)
-		}
-		score.Home = append(score.Home, homeScore)
+// getOvertimeLabel labels overtimes OT, 2OT, 3OT...
+func getOvertimeLabel(overtime int) string {
+	if overtime == 1 {
+		return "OT"
+	}
 
-		awayScore, err := getScoreForPeriod(match.AwayScore, period)
-		if err != nil {
-			return Score{}, fmt.Errorf("unable to get away score for period %s: %w", period, err)
+	return fmt.Sprintf("%dOT", overtime)
+}
+
+// countPeriods counts the consecutive numbered periods that have a score
+func countPeriods(score ClientScore) int {
+	count := 0
+	for {
+		if _, ok := score[fmt.Sprintf("period_%d", count+1)]; !ok {
+			return count
 		}
-		score.Away = append(score.Away, awayScore)
+		count++
 	}
+}
 
-	return score, nil
+// getTotal prefers the provider's display score, which is what broadcasters show, over its raw current score
+func getTotal(score ClientScore) string {
+	if total, ok := score[displayKey]; ok {
+		return total.String()
+	}
+	if total, ok := score[currentKey]; ok {
+		return total.String()
+	}
+
+	return ""
 }
 
 func getScoreForPeriod(score ClientScore, period string) (string, error) {
@@ -0,0 +1,251 @@
+package sports
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeTestMatch reads a match the way the provider sends it
+func decodeTestMatch(t *testing.T, payload string) ClientMatch {
+	t.Helper()
+
+	var match ClientMatch
+	if err := json.Unmarshal([]byte(payload), &match); err != nil {
+		t.Fatal(err)
+	}
+
+	return match
+}
+
+func TestFormatScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		scoringFunc ScoringFunc
+		payload     string
+		want        Score
+		wantErr     bool
+	}{
+		{
+			name:        "basketball",
+			scoringFunc: FormatBasketballScore,
+			payload: `{
+				"home_score": {"current": 101, "display": 101, "period_1": 25, "period_2": 30, "period_3": 22,
+					"period_4": 24},
+				"away_score": {"current": 99, "display": 99, "period_1": 20, "period_2": 28, "period_3": 27,
+					"period_4": 24}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1", "Q2", "Q3", "Q4"},
+				Home:      []string{"25", "30", "22", "24"},
+				HomeTotal: "101",
+				Away:      []string{"20", "28", "27", "24"},
+				AwayTotal: "99",
+			},
+		},
+		{
+			name:        "basketball in the first quarter",
+			scoringFunc: FormatBasketballScore,
+			payload: `{
+				"home_score": {"current": 8, "display": 8, "period_1": 8},
+				"away_score": {"current": 5, "display": 5, "period_1": 5}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1"},
+				Home:      []string{"8"},
+				HomeTotal: "8",
+				Away:      []string{"5"},
+				AwayTotal: "5",
+			},
+		},
+		{
+			name:        "basketball overtimes as further periods",
+			scoringFunc: FormatBasketballScore,
+			payload: `{
+				"default_period_count": 4,
+				"home_score": {"display": 120, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"period_5": 10, "period_6": 10},
+				"away_score": {"display": 118, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"period_5": 10, "period_6": 8}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1", "Q2", "Q3", "Q4", "OT", "2OT"},
+				Home:      []string{"25", "25", "25", "25", "10", "10"},
+				HomeTotal: "120",
+				Away:      []string{"25", "25", "25", "25", "10", "8"},
+				AwayTotal: "118",
+			},
+		},
+		{
+			name:        "basketball overtimes numbered apart",
+			scoringFunc: FormatBasketballScore,
+			payload: `{
+				"home_score": {"display": 121, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"overtime": 21, "overtime_1": 10, "overtime_2": 11},
+				"away_score": {"display": 119, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"overtime": 19, "overtime_1": 10, "overtime_2": 9}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1", "Q2", "Q3", "Q4", "OT", "2OT"},
+				Home:      []string{"25", "25", "25", "25", "10", "11"},
+				HomeTotal: "121",
+				Away:      []string{"25", "25", "25", "25", "10", "9"},
+				AwayTotal: "119",
+			},
+		},
+		{
+			name:        "basketball overtimes as one total",
+			scoringFunc: FormatBasketballScore,
+			payload: `{
+				"home_score": {"display": 110, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"overtime": 10},
+				"away_score": {"display": 108, "period_1": 25, "period_2": 25, "period_3": 25, "period_4": 25,
+					"overtime": 8}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1", "Q2", "Q3", "Q4", "OT"},
+				Home:      []string{"25", "25", "25", "25", "10"},
+				HomeTotal: "110",
+				Away:      []string{"25", "25", "25", "25", "8"},
+				AwayTotal: "108",
+			},
+		},
+		{
+			name:        "display total over the current one",
+			scoringFunc: FormatSoccerScore,
+			payload: `{
+				"home_score": {"current": 5, "display": 1, "period_1": 1, "period_2": 0, "overtime": 0,
+					"penalties": 4},
+				"away_score": {"current": 4, "display": 1, "period_1": 0, "period_2": 1, "overtime": 0,
+					"penalties": 3}
+			}`,
+			want: Score{
+				Periods:   []string{"1H", "2H", "ET", "PEN"},
+				Home:      []string{"1", "0", "0", "4"},
+				HomeTotal: "1",
+				Away:      []string{"0", "1", "0", "3"},
+				AwayTotal: "1",
+			},
+		},
+		{
+			name:        "current total without a display one",
+			scoringFunc: FormatSoccerScore,
+			payload: `{
+				"home_score": {"current": "2", "period_1": "2"},
+				"away_score": {"current": "0", "period_1": "0"}
+			}`,
+			want: Score{
+				Periods:   []string{"1H"},
+				Home:      []string{"2"},
+				HomeTotal: "2",
+				Away:      []string{"0"},
+				AwayTotal: "0",
+			},
+		},
+		{
+			name:        "no totals",
+			scoringFunc: FormatIceHockeyScore,
+			payload:     `{"home_score": {"period_1": 1}, "away_score": {"period_1": 0}}`,
+			want:        Score{Periods: []string{"P1"}, Home: []string{"1"}, Away: []string{"0"}},
+		},
+		{
+			name:        "ice hockey shootout",
+			scoringFunc: FormatIceHockeyScore,
+			payload: `{
+				"home_score": {"display": 3, "period_1": 1, "period_2": 0, "period_3": 1, "overtime": 0,
+					"penalties": 1},
+				"away_score": {"display": 2, "period_1": 0, "period_2": 1, "period_3": 1, "overtime": 0,
+					"penalties": 0}
+			}`,
+			want: Score{
+				Periods:   []string{"P1", "P2", "P3", "OT", "SO"},
+				Home:      []string{"1", "0", "1", "0", "1"},
+				HomeTotal: "3",
+				Away:      []string{"0", "1", "1", "0", "0"},
+				AwayTotal: "2",
+			},
+		},
+		{
+			name:        "american football overtime",
+			scoringFunc: FormatAmericanFootballScore,
+			payload: `{
+				"home_score": {"display": 27, "period_1": 7, "period_2": 7, "period_3": 3, "period_4": 4,
+					"overtime": 6},
+				"away_score": {"display": 21, "period_1": 0, "period_2": 14, "period_3": 0, "period_4": 7,
+					"overtime": 0}
+			}`,
+			want: Score{
+				Periods:   []string{"Q1", "Q2", "Q3", "Q4", "OT"},
+				Home:      []string{"7", "7", "3", "4", "6"},
+				HomeTotal: "27",
+				Away:      []string{"0", "14", "0", "7", "0"},
+				AwayTotal: "21",
+			},
+		},
+		{
+			name:        "baseball extra innings",
+			scoringFunc: FormatBaseballScore,
+			payload: `{
+				"home_score": {"display": 4, "period_1": 0, "period_2": 1, "period_3": 0, "period_4": 0,
+					"period_5": 2, "period_6": 0, "period_7": 0, "period_8": 0, "period_9": 0, "period_10": 1},
+				"away_score": {"display": 3, "period_1": 1, "period_2": 0, "period_3": 0, "period_4": 2,
+					"period_5": 0, "period_6": 0, "period_7": 0, "period_8": 0, "period_9": 0, "period_10": 0}
+			}`,
+			want: Score{
+				Periods:   []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+				Home:      []string{"0", "1", "0", "0", "2", "0", "0", "0", "0", "1"},
+				HomeTotal: "4",
+				Away:      []string{"1", "0", "0", "2", "0", "0", "0", "0", "0", "0"},
+				AwayTotal: "3",
+			},
+		},
+		{
+			name:        "period the away side has no score for",
+			scoringFunc: FormatBasketballScore,
+			payload:     `{"home_score": {"period_1": 20, "period_2": 4}, "away_score": {"period_1": 18}}`,
+			wantErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				score, err := test.scoringFunc(decodeTestMatch(t, test.payload))
+				if (err != nil) != test.wantErr {
+					t.Fatalf("error = %v, want error %t", err, test.wantErr)
+				}
+				if !test.wantErr && !reflect.DeepEqual(score, test.want) {
+					t.Errorf("score = %+v, want %+v", score, test.want)
+				}
+			},
+		)
+	}
+}
+
+func TestScoreGetPeriodLabels(t *testing.T) {
+	tests := []struct {
+		name  string
+		score Score
+		want  []string
+	}{
+		{
+			name:  "labelled",
+			score: Score{Periods: []string{"Q1", "OT"}, Home: []string{"20", "5"}},
+			want:  []string{"Q1", "OT"},
+		},
+		{name: "numbered", score: Score{Home: []string{"6", "4", "7"}}, want: []string{"1", "2", "3"}},
+		{
+			name:  "labels that don't match the periods",
+			score: Score{Periods: []string{"1H"}, Home: []string{"1", "0"}},
+			want:  []string{"1", "2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				if got := test.score.GetPeriodLabels(); !reflect.DeepEqual(got, test.want) {
+					t.Errorf("GetPeriodLabels() = %v, want %v", got, test.want)
+				}
+			},
+		)
+	}
+}
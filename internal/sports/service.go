@@ -2,28 +2,79 @@ package sports
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// startAtLayout is how the provider formats match start times, always in UTC
+const startAtLayout = "2006-01-02 15:04:05"
+
+// historySaveTimeout bounds recording an update in history, apart from the update's own timeout which fetching it
+// may have mostly used up
+const historySaveTimeout = 10 * time.Second
+
+var (
+	ErrMatchNotFound = errors.New("match not found")
+	// ErrMatchesUnavailable is returned for sports that haven't been updated successfully since starting up
+	ErrMatchesUnavailable = errors.New("matches unavailable")
+)
+
 type Service interface {
+	GetMatch(ctx context.Context, id int) (Match, error)
 	GetMatches(ctx context.Context, gameType GameType, live bool) ([]Match, error)
+	// GetStatus reports how fresh the cached matches of a sport are, ok is false until they've been updated once
+	GetStatus(ctx context.Context, gameType GameType, live bool) (status CacheStatus, ok bool)
+	// GetMatchesWithStatus and GetMatchWithStatus read matches along with the status of the update they came from, so
+	// the status is always the one of the matches returned
+	GetMatchesWithStatus(ctx context.Context, gameType GameType, live bool) ([]Match, CacheStatus, error)
+	GetMatchWithStatus(ctx context.Context, id int) (Match, CacheStatus, error)
 	UpdateMatches(ctx context.Context, live bool) error
 }
 
+// CacheStatus describes the matches cached for a sport
+type CacheStatus struct {
+	// Version changes whenever the cached matches do, so it can be used as an etag for anything derived from them
+	Version   string
+	UpdatedAt time.Time
+	// Stale is set when the last update failed and the matches are left over from an earlier one
+	Stale bool
+	Err   error
+}
+
+type cacheEntry struct {
+	matches []Match
+	status  CacheStatus
+}
+
 type service struct {
-	cache  map[string][]Match
+	cache  map[string]cacheEntry
 	mutex  *sync.RWMutex
 	client Client
+	// updateTimeout bounds how long each sport can take to update, so one slow sport doesn't hold up the rest
+	updateTimeout time.Duration
+	// events are published after every update of live matches
+	events *Broker
+	// history records every update when it's set
+	history HistoryRepository
 }
 
-func NewService(client Client) Service {
+// NewService returns a service caching matches in memory, history can be nil to not keep any
+func NewService(client Client, updateTimeout time.Duration, events *Broker, history HistoryRepository) Service {
 	return &service{
-		cache:  make(map[string][]Match),
-		mutex:  &sync.RWMutex{},
-		client: client,
+		cache:         make(map[string]cacheEntry),
+		mutex:         &sync.RWMutex{},
+		client:        client,
+		updateTimeout: updateTimeout,
+		events:        events,
+		history:       history,
 	}
 }
 
@@ -31,36 +82,106 @@ func (s *service) GetMatches(_ context.Context, gameType GameType, live bool) ([
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	matches, ok := s.cache[s.getKey(gameType, live)]
+	entry, ok := s.cache[s.getKey(gameType, live)]
 	if !ok {
-		return nil, fmt.Errorf("no matches found for %s", gameType)
+		return nil, fmt.Errorf("%w for %s", ErrMatchesUnavailable, gameType)
 	}
 
-	return matches, nil
+	return entry.matches, nil
 }
 
-func (s *service) UpdateMatches(ctx context.Context, live bool) error {
-	err := s.updateMatches(ctx, Tennis, FormatTennisScore, live)
-	if err != nil {
-		return err
+func (s *service) GetStatus(_ context.Context, gameType GameType, live bool) (CacheStatus, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, ok := s.cache[s.getKey(gameType, live)]
+
+	return entry.status, ok
+}
+
+func (s *service) GetMatchesWithStatus(_ context.Context, gameType GameType, live bool) (
+	[]Match,
+	CacheStatus,
+	error,
+) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, ok := s.cache[s.getKey(gameType, live)]
+	if !ok {
+		return nil, CacheStatus{}, fmt.Errorf("%w for %s", ErrMatchesUnavailable, gameType)
 	}
-	err = s.updateMatches(ctx, Basketball, FormatBasketballScore, live)
-	if err != nil {
-		return err
+
+	return entry.matches, entry.status, nil
+}
+
+// GetMatch looks up a match by id in every cached set of matches
+func (s *service) GetMatch(ctx context.Context, id int) (Match, error) {
+	match, _, err := s.GetMatchWithStatus(ctx, id)
+	return match, err
+}
+
+func (s *service) GetMatchWithStatus(_ context.Context, id int) (Match, CacheStatus, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, entry := range s.cache {
+		for _, match := range entry.matches {
+			if match.ID == id {
+				return match, entry.status, nil
+			}
+		}
 	}
 
-	return nil
+	return Match{}, CacheStatus{}, fmt.Errorf("%w: %d", ErrMatchNotFound, id)
+}
+
+// UpdateMatches updates every sport at once. A sport that fails to update keeps its last matches, marked as stale,
+// and doesn't stop the others from updating
+func (s *service) UpdateMatches(ctx context.Context, live bool) error {
+	var wg sync.WaitGroup
+	var errsMutex sync.Mutex
+	var errs []error
+
+	for _, sport := range Sports() {
+		wg.Add(1)
+		go func(sport Sport) {
+			defer wg.Done()
+
+			err := s.updateMatches(ctx, sport.GameType, sport.ScoringFunc, live)
+			if err == nil {
+				return
+			}
+
+			s.markStale(sport.GameType, live, err)
+
+			errsMutex.Lock()
+			defer errsMutex.Unlock()
+			errs = append(errs, fmt.Errorf("%s: %w", sport.GameType, err))
+		}(sport)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
 }
 
 func (s *service) updateMatches(ctx context.Context, gameType GameType, scoringFunc ScoringFunc, live bool) error {
 	zap.S().Infof("Updating matches for %s", gameType)
 
+	fetchCtx := ctx
+	if s.updateTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, s.updateTimeout)
+		defer cancel()
+	}
+
 	var response ClientMatchResponse
 	var err error
 	if live {
-		response, err = s.client.GetLiveMatches(ctx, gameType)
+		response, err = s.client.GetLiveMatches(fetchCtx, gameType)
 	} else {
-		response, err = s.client.GetMatches(ctx, gameType)
+		response, err = s.client.GetMatches(fetchCtx, gameType)
 	}
 	if err != nil {
 		return fmt.Errorf("unable to get matches: %w", err)
@@ -74,25 +195,99 @@ func (s *service) updateMatches(ctx context.Context, gameType GameType, scoringF
 			continue
 		}
 
-		matches = append(
-			matches, Match{
-				GameType: gameType,
-				Home:     Team{Name: match.HomeTeam.Name},
-				Away:     Team{Name: match.AwayTeam.Name},
-				Score:    score,
-			},
-		)
+		matches = append(matches, newMatch(gameType, match, score))
 	}
 
 	zap.S().Infof("Updated %d %s matches", len(matches), gameType)
 
+	now := time.Now()
+	key := s.getKey(gameType, live)
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	s.cache[s.getKey(gameType, live)] = matches
+	previous, updated := s.cache[key]
+	s.cache[key] = cacheEntry{
+		matches: matches,
+		status:  CacheStatus{Version: getMatchesVersion(matches), UpdatedAt: now},
+	}
+	s.mutex.Unlock()
+
+	// Only live matches are compared, as matches finish by dropping out of them. Nothing is known to have changed
+	// before there's an update to compare with, so starting up doesn't announce every match that's already live
+	if live && updated {
+		s.events.Publish(getMatchEvents(previous.matches, matches, now))
+	}
+
+	if s.history != nil {
+		s.saveHistory(ctx, gameType, live, matches, now)
+	}
 
 	return nil
 }
 
+// saveHistory records an update of a sport. The cache is what's served, so failing to record history is only logged
+// rather than making the matches stale
+func (s *service) saveHistory(ctx context.Context, gameType GameType, live bool, matches []Match, seenAt time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, historySaveTimeout)
+	defer cancel()
+
+	if err := s.history.SaveMatches(ctx, gameType, live, matches, seenAt); err != nil {
+		zap.S().Errorw(fmt.Sprintf("Unable to record %s match history", gameType), zap.Error(err))
+	}
+}
+
+// markStale flags the matches left over from the last successful update, if there was one
+func (s *service) markStale(gameType GameType, live bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := s.getKey(gameType, live)
+	entry, ok := s.cache[key]
+	if !ok {
+		return
+	}
+
+	entry.status.Stale = true
+	entry.status.Err = err
+	s.cache[key] = entry
+}
+
+func newTeam(team ClientTeam) Team {
+	return Team{
+		Name:      team.Name,
+		ShortName: team.NameShort,
+		Code:      team.NameCode,
+		Logo:      getImageURL(team.Logo, team.HasLogo),
+		// Flags are shared by every team from a country, so the provider doesn't say whether it has one
+		Flag:        getImageURL(team.Flag, true),
+		CountryCode: team.CountryCode,
+	}
+}
+
+// getImageURL only keeps absolute http URLs, the provider fills image fields with placeholders when it has none
+func getImageURL(imageURL string, ok bool) string {
+	if !ok {
+		return ""
+	}
+
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+
+	return imageURL
+}
+
+// getMatchesVersion hashes matches so updates that don't change anything keep the same version
+func getMatchesVersion(matches []Match) string {
+	hash := fnv.New64a()
+	if err := json.NewEncoder(hash).Encode(matches); err != nil {
+		// Fall back to a version that's never reused so nothing derived from the old matches is served
+		zap.S().Errorw("Unable to hash matches", zap.Error(err))
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
 func (s *service) getKey(gameType GameType, live bool) string {
 	var liveStr string
 	if live {
@@ -101,3 +296,29 @@ func (s *service) getKey(gameType GameType, live bool) string {
 
 	return fmt.Sprintf("%s_%s", gameType, liveStr)
 }
+
+func newMatch(gameType GameType, match ClientMatch, score Score) Match {
+	startAt, err := time.Parse(startAtLayout, match.StartAt)
+	if err != nil {
+		zap.S().Debugw("Unable to parse match start time", zap.Int("id", match.ID), zap.Error(err))
+	}
+
+	var currentPeriodStartAt time.Time
+	if match.TimeDetails.CurrentPeriodStartTimestamp > 0 {
+		currentPeriodStartAt = time.Unix(int64(match.TimeDetails.CurrentPeriodStartTimestamp), 0).UTC()
+	}
+
+	return Match{
+		ID:                   match.ID,
+		GameType:             gameType,
+		Home:                 newTeam(match.HomeTeam),
+		Away:                 newTeam(match.AwayTeam),
+		Score:                score,
+		League:               match.League.Name,
+		Season:               match.Season.Name,
+		Status:               match.Status,
+		StatusMore:           match.StatusMore,
+		StartAt:              startAt,
+		CurrentPeriodStartAt: currentPeriodStartAt,
+	}
+}
@@ -0,0 +1,87 @@
+package sports
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// stubClient serves live matches numbered by sport and update, failing the sports in errs
+type stubClient struct {
+	Client
+	update int
+	errs   map[GameType]error
+}
+
+func (c *stubClient) GetLiveMatches(_ context.Context, gameType GameType) (ClientMatchResponse, error) {
+	if err, ok := c.errs[gameType]; ok {
+		return ClientMatchResponse{}, err
+	}
+
+	return ClientMatchResponse{Matches: []ClientMatch{{ID: getStubMatchID(gameType, c.update)}}}, nil
+}
+
+func getStubMatchID(gameType GameType, update int) int {
+	return int(gameType)*100 + update
+}
+
+func TestServiceUpdateMatchesFailedSport(t *testing.T) {
+	errProvider := errors.New("provider unavailable")
+	client := &stubClient{update: 1}
+	s := NewService(client, 0, NewBroker(), nil)
+	ctx := context.Background()
+
+	// Tennis can't be updated at first, so it has no matches to fall back to
+	client.errs = map[GameType]error{Tennis: errProvider}
+	if err := s.UpdateMatches(ctx, true); !errors.Is(err, errProvider) {
+		t.Fatalf("UpdateMatches() error = %v, want %v", err, errProvider)
+	}
+	if _, _, err := s.GetMatchesWithStatus(ctx, Tennis, true); !errors.Is(err, ErrMatchesUnavailable) {
+		t.Errorf("GetMatchesWithStatus(Tennis) error = %v, want %v", err, ErrMatchesUnavailable)
+	}
+
+	client.errs = nil
+	if err := s.UpdateMatches(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	tennisStatus, _ := s.GetStatus(ctx, Tennis, true)
+
+	// Then it fails again after an update that worked, while every other sport goes on updating
+	client.update, client.errs = 2, map[GameType]error{Tennis: errProvider}
+	err := s.UpdateMatches(ctx, true)
+	if !errors.Is(err, errProvider) {
+		t.Fatalf("UpdateMatches() error = %v, want %v", err, errProvider)
+	}
+
+	for _, sport := range Sports() {
+		t.Run(
+			sport.Slug, func(t *testing.T) {
+				matches, status, err := s.GetMatchesWithStatus(ctx, sport.GameType, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				wantUpdate, wantStale := 2, false
+				if sport.GameType == Tennis {
+					wantUpdate, wantStale = 1, true
+				}
+				ids := make([]int, 0, len(matches))
+				for _, match := range matches {
+					ids = append(ids, match.ID)
+				}
+				if want := []int{getStubMatchID(sport.GameType, wantUpdate)}; !slices.Equal(ids, want) {
+					t.Errorf("matches %v, want %v", ids, want)
+				}
+				if status.Stale != wantStale || (status.Err != nil) != wantStale {
+					t.Errorf("status = %+v, want stale %t", status, wantStale)
+				}
+				// Stale matches keep the version and time they were updated at, they're only flagged
+				unchanged := status.Version == tennisStatus.Version && status.UpdatedAt.Equal(tennisStatus.UpdatedAt)
+				if wantStale && !unchanged {
+					t.Errorf("stale status = %+v, want the version and time of %+v", status, tennisStatus)
+				}
+			},
+		)
+	}
+}
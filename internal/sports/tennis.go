@@ -0,0 +1,171 @@
+package sports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Side is one of the two players, or pairs, in a tennis match
+type Side int
+
+const (
+	NoSide Side = iota
+	HomeSide
+	AwaySide
+)
+
+// Provider keys for the point currently being played and a set's tiebreak
+const (
+	pointKey          = "point"
+	tiebreakKeyFormat = "period_%d_tie_break"
+)
+
+// Games needed to win a set, a set at 6-6 is decided by a tiebreak
+const gamesPerSet = 6
+
+type TennisScore struct {
+	HomeSets int
+	AwaySets int
+	Sets     []TennisSet
+	// HomePoint and AwayPoint are the score of the current game: 0, 15, 30, 40 or AD. They're empty between games.
+	HomePoint string
+	AwayPoint string
+	Server    Side
+}
+
+type TennisSet struct {
+	HomeGames int
+	AwayGames int
+	// HomeTiebreak and AwayTiebreak are empty when the set had no tiebreak
+	HomeTiebreak string
+	AwayTiebreak string
+}
+
+// FormatTennisScore reads the games of every set played so far along with tiebreaks, the current game and who's
+// serving. Home and Away hold the games per set, HomeTotal and AwayTotal the sets won.
+func FormatTennisScore(match ClientMatch) (Score, error) {
+	tennis := &TennisScore{
+		HomePoint: getTennisPoint(match.HomeScore),
+		AwayPoint: getTennisPoint(match.AwayScore),
+		Server:    getServer(match.FirstSupply),
+	}
+
+	setCount := countPeriods(match.HomeScore)
+	score := Score{
+		Home:   make([]string, 0, setCount),
+		Away:   make([]string, 0, setCount),
+		Tennis: tennis,
+	}
+
+	for _, period := range getPeriodKeys(setCount) {
+		homeGames, err := getGamesForPeriod(match.HomeScore, period)
+		if err != nil {
+			return Score{}, fmt.Errorf("unable to get home score for period %s: %w", period, err)
+		}
+		awayGames, err := getGamesForPeriod(match.AwayScore, period)
+		if err != nil {
+			return Score{}, fmt.Errorf("unable to get away score for period %s: %w", period, err)
+		}
+
+		set := TennisSet{
+			HomeGames:    homeGames,
+			AwayGames:    awayGames,
+			HomeTiebreak: getTiebreak(match.HomeScore, len(tennis.Sets)+1),
+			AwayTiebreak: getTiebreak(match.AwayScore, len(tennis.Sets)+1),
+		}
+		tennis.Sets = append(tennis.Sets, set)
+		score.Home = append(score.Home, strconv.Itoa(homeGames))
+		score.Away = append(score.Away, strconv.Itoa(awayGames))
+	}
+
+	tennis.HomeSets, tennis.AwaySets = countSetsWon(match, tennis.Sets)
+	score.HomeTotal = strconv.Itoa(tennis.HomeSets)
+	score.AwayTotal = strconv.Itoa(tennis.AwaySets)
+
+	return score, nil
+}
+
+func getGamesForPeriod(score ClientScore, period string) (int, error) {
+	games, err := getScoreForPeriod(score, period)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(games)
+}
+
+func getTiebreak(score ClientScore, set int) string {
+	tiebreak, ok := score[fmt.Sprintf(tiebreakKeyFormat, set)]
+	if !ok {
+		return ""
+	}
+
+	return tiebreak.String()
+}
+
+// getTennisPoint normalizes the provider's point, which reports advantage as A or 50
+func getTennisPoint(score ClientScore) string {
+	point, ok := score[pointKey]
+	if !ok {
+		return ""
+	}
+
+	switch p := strings.ToUpper(point.String()); p {
+	case "A", "AD", "50":
+		return "AD"
+	default:
+		return p
+	}
+}
+
+// getServer reads first_supply, which is 1 when the home side is serving and 2 for the away side
+func getServer(firstSupply interface{}) Side {
+	switch fmt.Sprint(firstSupply) {
+	case "1":
+		return HomeSide
+	case "2":
+		return AwaySide
+	default:
+		return NoSide
+	}
+}
+
+// countSetsWon prefers the provider's running total and falls back to counting finished sets
+func countSetsWon(match ClientMatch, sets []TennisSet) (int, int) {
+	homeTotal, homeErr := strconv.Atoi(getTotal(match.HomeScore))
+	awayTotal, awayErr := strconv.Atoi(getTotal(match.AwayScore))
+	if homeErr == nil && awayErr == nil {
+		return homeTotal, awayTotal
+	}
+
+	var home, away int
+	for _, set := range sets {
+		switch getSetWinner(set) {
+		case HomeSide:
+			home++
+		case AwaySide:
+			away++
+		}
+	}
+
+	return home, away
+}
+
+// getSetWinner returns NoSide for sets that are still being played
+func getSetWinner(set TennisSet) Side {
+	leader, lead, games := HomeSide, set.HomeGames-set.AwayGames, set.HomeGames
+	if lead < 0 {
+		leader, lead, games = AwaySide, -lead, set.AwayGames
+	}
+
+	switch {
+	case games >= gamesPerSet && lead >= 2:
+		return leader
+	case games == gamesPerSet+1 && lead == 1:
+		// 7-6 after a tiebreak
+		return leader
+	default:
+		return NoSide
+	}
+}
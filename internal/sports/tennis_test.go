@@ -0,0 +1,189 @@
+package sports
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFormatTennisScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    TennisScore
+		wantErr bool
+	}{
+		{
+			name: "first game",
+			payload: `{
+				"first_supply": 1,
+				"home_score": {"current": 0, "period_1": 0, "point": "15"},
+				"away_score": {"current": 0, "period_1": 0, "point": "0"}
+			}`,
+			want: TennisScore{
+				Sets:      []TennisSet{{}},
+				HomePoint: "15",
+				AwayPoint: "0",
+				Server:    HomeSide,
+			},
+		},
+		{
+			name: "deuce",
+			payload: `{
+				"first_supply": 2,
+				"home_score": {"current": 0, "period_1": 3, "point": "40"},
+				"away_score": {"current": 0, "period_1": 2, "point": "40"}
+			}`,
+			want: TennisScore{
+				Sets:      []TennisSet{{HomeGames: 3, AwayGames: 2}},
+				HomePoint: "40",
+				AwayPoint: "40",
+				Server:    AwaySide,
+			},
+		},
+		{
+			name: "advantage as A",
+			payload: `{
+				"first_supply": "2",
+				"home_score": {"period_1": 3, "point": "A"},
+				"away_score": {"period_1": 2, "point": "40"}
+			}`,
+			want: TennisScore{
+				Sets:      []TennisSet{{HomeGames: 3, AwayGames: 2}},
+				HomePoint: "AD",
+				AwayPoint: "40",
+				Server:    AwaySide,
+			},
+		},
+		{
+			name: "advantage as 50",
+			payload: `{
+				"home_score": {"period_1": 3, "point": "40"},
+				"away_score": {"period_1": 2, "point": 50}
+			}`,
+			want: TennisScore{
+				Sets:      []TennisSet{{HomeGames: 3, AwayGames: 2}},
+				HomePoint: "40",
+				AwayPoint: "AD",
+			},
+		},
+		{
+			name: "advantage as ad",
+			payload: `{
+				"home_score": {"period_1": 3, "point": "ad"},
+				"away_score": {"period_1": 2, "point": "40"}
+			}`,
+			want: TennisScore{
+				Sets:      []TennisSet{{HomeGames: 3, AwayGames: 2}},
+				HomePoint: "AD",
+				AwayPoint: "40",
+			},
+		},
+		{
+			name: "between games without a server",
+			payload: `{
+				"first_supply": null,
+				"home_score": {"period_1": 4},
+				"away_score": {"period_1": 2}
+			}`,
+			want: TennisScore{Sets: []TennisSet{{HomeGames: 4, AwayGames: 2}}},
+		},
+		{
+			name: "tiebreak sets",
+			payload: `{
+				"first_supply": 1,
+				"home_score": {"current": 1, "period_1": 7, "period_1_tie_break": 7, "period_2": 6,
+					"period_2_tie_break": 10, "period_3": 1, "point": "30"},
+				"away_score": {"current": 1, "period_1": 6, "period_1_tie_break": 5, "period_2": 7,
+					"period_2_tie_break": 12, "period_3": 1, "point": "30"}
+			}`,
+			want: TennisScore{
+				HomeSets: 1,
+				AwaySets: 1,
+				Sets: []TennisSet{
+					{HomeGames: 7, AwayGames: 6, HomeTiebreak: "7", AwayTiebreak: "5"},
+					{HomeGames: 6, AwayGames: 7, HomeTiebreak: "10", AwayTiebreak: "12"},
+					{HomeGames: 1, AwayGames: 1},
+				},
+				HomePoint: "30",
+				AwayPoint: "30",
+				Server:    HomeSide,
+			},
+		},
+		{
+			name: "sets won from the provider's total",
+			payload: `{
+				"home_score": {"current": 2, "display": 2, "period_1": 6, "period_2": 6},
+				"away_score": {"current": 0, "display": 0, "period_1": 4, "period_2": 4}
+			}`,
+			want: TennisScore{
+				HomeSets: 2,
+				Sets:     []TennisSet{{HomeGames: 6, AwayGames: 4}, {HomeGames: 6, AwayGames: 4}},
+			},
+		},
+		{
+			name: "sets won counted without a total",
+			payload: `{
+				"home_score": {"period_1": 6, "period_2": 7, "period_3": 5, "period_4": 7, "period_5": 5},
+				"away_score": {"period_1": 4, "period_2": 6, "period_3": 7, "period_4": 5, "period_5": 5}
+			}`,
+			want: TennisScore{
+				HomeSets: 3,
+				AwaySets: 1,
+				Sets: []TennisSet{
+					{HomeGames: 6, AwayGames: 4},
+					{HomeGames: 7, AwayGames: 6},
+					{HomeGames: 5, AwayGames: 7},
+					{HomeGames: 7, AwayGames: 5},
+					{HomeGames: 5, AwayGames: 5},
+				},
+			},
+		},
+		{
+			name: "sets still being played aren't counted",
+			payload: `{
+				"home_score": {"period_1": 6, "period_2": 7},
+				"away_score": {"period_1": 5, "period_2": 7}
+			}`,
+			want: TennisScore{Sets: []TennisSet{{HomeGames: 6, AwayGames: 5}, {HomeGames: 7, AwayGames: 7}}},
+		},
+		{
+			name:    "games that aren't a number",
+			payload: `{"home_score": {"period_1": "six"}, "away_score": {"period_1": 4}}`,
+			wantErr: true,
+		},
+		{
+			name:    "set the away side has no games in",
+			payload: `{"home_score": {"period_1": 6, "period_2": 1}, "away_score": {"period_1": 4}}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				score, err := FormatTennisScore(decodeTestMatch(t, test.payload))
+				if (err != nil) != test.wantErr {
+					t.Fatalf("error = %v, want error %t", err, test.wantErr)
+				}
+				if test.wantErr {
+					return
+				}
+				if score.Tennis == nil {
+					t.Fatal("score has no tennis score")
+				}
+				if !reflect.DeepEqual(*score.Tennis, test.want) {
+					t.Errorf("tennis score = %+v, want %+v", *score.Tennis, test.want)
+				}
+
+				// Games per set and sets won are also read like other sports' periods and totals
+				if len(score.Home) != len(test.want.Sets) || len(score.Away) != len(test.want.Sets) {
+					t.Errorf("score has %d and %d sets, want %d", len(score.Home), len(score.Away), len(test.want.Sets))
+				}
+				wantTotals := []string{strconv.Itoa(test.want.HomeSets), strconv.Itoa(test.want.AwaySets)}
+				if totals := []string{score.HomeTotal, score.AwayTotal}; !reflect.DeepEqual(totals, wantTotals) {
+					t.Errorf("totals = %v, want %v", totals, wantTotals)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,199 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/welps/go-frames-scores/internal/api"
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+)
+
+const (
+	// writeTimeout drops clients that stop reading, so they can't hold a stream open forever
+	writeTimeout = 10 * time.Second
+	// maxSocketMessageSize is as much as clients can send, they've nothing to send beyond pongs and closes
+	maxSocketMessageSize = 512
+)
+
+// Options control how events are streamed
+type Options struct {
+	// Heartbeat is how often idle streams are written to, so proxies keep them open and gone clients are noticed
+	Heartbeat time.Duration
+	// BufferSize is how many events a client can fall behind by before it's dropped
+	BufferSize int
+}
+
+type Controller struct {
+	broker   *sports.Broker
+	options  Options
+	upgrader websocket.Upgrader
+}
+
+func NewController(broker *sports.Broker, options Options) (*Controller, error) {
+	switch {
+	case options.Heartbeat <= 0:
+		return nil, fmt.Errorf("stream heartbeat must be positive")
+	case options.BufferSize <= 0:
+		return nil, fmt.Errorf("stream buffer size must be positive")
+	}
+
+	return &Controller{
+		broker:  broker,
+		options: options,
+		upgrader: websocket.Upgrader{
+			// Events are public like the rest of the API, which allows every origin
+			CheckOrigin: func(*http.Request) bool {
+				return true
+			},
+		},
+	}, nil
+}
+
+// GetEvents streams events as server-sent events named after their type
+func (c *Controller) GetEvents(ctx *gin.Context) {
+	subscription, ok := c.subscribe(ctx)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	// Proxies like nginx otherwise buffer events until there's a page of them
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(c.options.Heartbeat)
+	defer heartbeat.Stop()
+	controller := http.NewResponseController(ctx.Writer)
+	ctx.Stream(
+		func(w io.Writer) bool {
+			select {
+			case event, ok := <-subscription.Events():
+				if !ok {
+					logSubscriptionEnd(subscription.Err())
+					return false
+				}
+				_ = controller.SetWriteDeadline(time.Now().Add(writeTimeout))
+				ctx.SSEvent(string(event.Type), api.NewEvent(event))
+			case <-heartbeat.C:
+				// Comments are ignored by clients, so they keep the stream open without being seen
+				_ = controller.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+					return false
+				}
+			case <-ctx.Request.Context().Done():
+				return false
+			}
+
+			return true
+		},
+	)
+}
+
+// GetEventsSocket streams events over a WebSocket as JSON messages, pinging clients to check they're still there
+func (c *Controller) GetEventsSocket(ctx *gin.Context) {
+	subscription, ok := c.subscribe(ctx)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// The upgrader has already responded with the error
+		zap.S().Debugw("Unable to upgrade to websocket", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	gone := make(chan struct{})
+	go c.readSocket(conn, gone)
+
+	heartbeat := time.NewTicker(c.options.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				logSubscriptionEnd(subscription.Err())
+				closeSocket(conn, subscription.Err())
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(api.NewEvent(event)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// readSocket handles pongs and closes until the client goes away, closing gone once it has. Clients that miss two
+// heartbeats in a row are taken to be gone
+func (c *Controller) readSocket(conn *websocket.Conn, gone chan<- struct{}) {
+	defer close(gone)
+
+	conn.SetReadLimit(maxSocketMessageSize)
+	extendDeadline := func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * c.options.Heartbeat))
+	}
+	_ = extendDeadline("")
+	conn.SetPongHandler(extendDeadline)
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// closeSocket tells the client why its subscription ended, clients that were too slow can reconnect straight away
+func closeSocket(conn *websocket.Conn, err error) {
+	code, reason := websocket.CloseNormalClosure, ""
+	switch {
+	case errors.Is(err, sports.ErrSubscriberTooSlow):
+		code, reason = websocket.CloseTryAgainLater, err.Error()
+	case errors.Is(err, sports.ErrBrokerClosed):
+		code, reason = websocket.CloseGoingAway, "server shutting down"
+	}
+
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+}
+
+func logSubscriptionEnd(err error) {
+	if errors.Is(err, sports.ErrSubscriberTooSlow) {
+		zap.S().Infow("Dropping slow event stream", zap.Error(err))
+	}
+}
+
+// subscribe subscribes to the sports in the sports query, a comma separated list of slugs, or to every sport when
+// it's empty
+func (c *Controller) subscribe(ctx *gin.Context) (*sports.Subscription, bool) {
+	var gameTypes []sports.GameType
+	if slugs := ctx.Query("sports"); slugs != "" {
+		for _, slug := range strings.Split(slugs, ",") {
+			sport, ok := sports.GetSportBySlug(strings.TrimSpace(slug))
+			if !ok {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown sport %q", slug)})
+				return nil, false
+			}
+			gameTypes = append(gameTypes, sport.GameType)
+		}
+	}
+
+	return c.broker.Subscribe(gameTypes, c.options.BufferSize), true
+}
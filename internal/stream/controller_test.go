@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/welps/go-frames-scores/internal/api"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+const testHeartbeat = 20 * time.Millisecond
+
+// newTestServer serves streams of broker's events the way the server does
+func newTestServer(t *testing.T, broker *sports.Broker) *httptest.Server {
+	t.Helper()
+
+	controller, err := NewController(broker, Options{Heartbeat: testHeartbeat, BufferSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/events", controller.GetEvents)
+	r.GET("/events/ws", controller.GetEventsSocket)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// getTestEvents are a tennis and a basketball event, streams for basketball only get the second
+func getTestEvents() []sports.Event {
+	return []sports.Event{
+		{Type: sports.EventScoreChanged, Match: sports.Match{ID: 1, GameType: sports.Tennis}},
+		{Type: sports.EventMatchStarted, Match: sports.Match{ID: 2, GameType: sports.Basketball}},
+	}
+}
+
+func TestNewControllerOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{name: "valid", options: Options{Heartbeat: time.Second, BufferSize: 1}},
+		{name: "no heartbeat", options: Options{BufferSize: 1}, wantErr: true},
+		{name: "negative heartbeat", options: Options{Heartbeat: -time.Second, BufferSize: 1}, wantErr: true},
+		{name: "no buffer", options: Options{Heartbeat: time.Second}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				_, err := NewController(sports.NewBroker(), test.options)
+				if (err != nil) != test.wantErr {
+					t.Errorf("NewController() error = %v, want error %t", err, test.wantErr)
+				}
+			},
+		)
+	}
+}
+
+func TestControllerGetEvents(t *testing.T) {
+	broker := sports.NewBroker()
+	server := newTestServer(t, broker)
+
+	response, err := http.Get(server.URL + "/events?sports=basketball")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events = %d with content type %q", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	// Idle streams are kept open with heartbeats, the subscription is in place once the headers are sent
+	lines := bufio.NewScanner(response.Body)
+	if !lines.Scan() || lines.Text() != ": heartbeat" {
+		t.Fatalf("idle stream wrote %q, want a heartbeat", lines.Text())
+	}
+
+	broker.Publish(getTestEvents())
+	var eventType, data string
+	for lines.Scan() && (eventType == "" || data == "") {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimPrefix(line, "data:")
+		}
+	}
+	if eventType != string(sports.EventMatchStarted) {
+		t.Errorf("event named %q, want %q", eventType, sports.EventMatchStarted)
+	}
+	var event api.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("event data %q: %v", data, err)
+	}
+	if event.Match.ID != 2 || event.Sport != "basketball" {
+		t.Errorf("received %+v, want the basketball match", event)
+	}
+
+	// Shutting down ends the stream
+	broker.Close()
+	for lines.Scan() {
+		if line := lines.Text(); line != "" && line != ": heartbeat" {
+			t.Errorf("stream wrote %q after the broker closed", line)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		t.Errorf("stream ended with %v", err)
+	}
+}
+
+func TestControllerGetEventsSocket(t *testing.T) {
+	broker := sports.NewBroker()
+	server := newTestServer(t, broker)
+
+	socketURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/ws?sports=basketball"
+	conn, _, err := websocket.DefaultDialer.Dial(socketURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(
+		func(data string) error {
+			select {
+			case pinged <- struct{}{}:
+			default:
+			}
+			// The server may be gone by the time the pong is written, which isn't what's being tested
+			_ = conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+			return nil
+		},
+	)
+
+	// The server hangs up straight after closing, so there's no echoing the close back
+	conn.SetCloseHandler(
+		func(int, string) error {
+			return nil
+		},
+	)
+
+	// The subscription is in place once the connection is upgraded
+	broker.Publish(getTestEvents())
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var event api.Event
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != sports.EventMatchStarted || event.Match.ID != 2 {
+		t.Errorf("received %+v, want the basketball match starting", event)
+	}
+
+	// Pings are only seen while reading, so the broker is closed once one arrives to end the read
+	go func() {
+		<-pinged
+		broker.Close()
+	}()
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("read after the broker closed = %v, want close %d", err, websocket.CloseGoingAway)
+	}
+}
+
+func TestControllerUnknownSport(t *testing.T) {
+	server := newTestServer(t, sports.NewBroker())
+
+	for _, path := range []string{"/events?sports=tennis,curling", "/events/ws?sports=curling"} {
+		t.Run(
+			path, func(t *testing.T) {
+				response, err := http.Get(server.URL + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer response.Body.Close()
+				if response.StatusCode != http.StatusBadRequest {
+					t.Errorf("GET %s = %d, want %d", path, response.StatusCode, http.StatusBadRequest)
+				}
+			},
+		)
+	}
+}
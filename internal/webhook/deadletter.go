@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// deadLetter is a delivery that was given up on, as it's written to the dead letter log
+type deadLetter struct {
+	ID         string          `json:"id"`
+	Subscriber string          `json:"subscriber"`
+	URL        string          `json:"url"`
+	Event      string          `json:"event"`
+	Attempts   int             `json:"attempts"`
+	Error      string          `json:"error"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// deadLetterLog appends deliveries that were given up on to a file of JSON lines, so they can be looked into or
+// replayed by hand. Without a file they're only logged
+type deadLetterLog struct {
+	file  *os.File
+	mutex *sync.Mutex
+}
+
+func newDeadLetterLog(path string) (*deadLetterLog, error) {
+	log := &deadLetterLog{mutex: &sync.Mutex{}}
+	if path == "" {
+		return log, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create dead letter directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open dead letter log: %w", err)
+	}
+	log.file = file
+
+	return log, nil
+}
+
+func (l *deadLetterLog) write(d *delivery, err error) {
+	zap.S().Errorw(
+		"Giving up on webhook delivery",
+		zap.String("id", d.id),
+		zap.String("subscriber", d.target.Name),
+		zap.String("event", string(d.eventType)),
+		zap.Int("attempts", d.attempts),
+		zap.Error(err),
+	)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.file == nil {
+		return
+	}
+
+	line, writeErr := json.Marshal(
+		deadLetter{
+			ID:         d.id,
+			Subscriber: d.target.Name,
+			URL:        d.target.URL,
+			Event:      string(d.eventType),
+			Attempts:   d.attempts,
+			Error:      err.Error(),
+			FailedAt:   time.Now().UTC(),
+			Payload:    d.payload,
+		},
+	)
+	if writeErr == nil {
+		_, writeErr = l.file.Write(append(line, '\n'))
+	}
+	if writeErr != nil {
+		zap.S().Errorw("Unable to write to dead letter log", zap.Error(writeErr))
+	}
+}
+
+func (l *deadLetterLog) close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
@@ -0,0 +1,330 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/welps/go-frames-scores/internal/api"
+	"github.com/welps/go-frames-scores/internal/sports"
+	"go.uber.org/zap"
+)
+
+// Every delivery is posted with these headers. The signature is "sha256=" followed by the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed by the subscriber's secret. Receivers should check it and that the timestamp
+// is recent, the delivery id stays the same across retries so they can drop duplicates
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	errQueueFull    = errors.New("delivery queue is full")
+	errShuttingDown = errors.New("server shutting down before delivery")
+)
+
+// Options control how deliveries are made and retried
+type Options struct {
+	// MaxAttempts is how many times a delivery is tried before it's given up on
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Workers is how many deliveries can be made at once
+	Workers int
+	// QueueSize is how many deliveries can wait to be made or retried, any more are given up on straight away
+	QueueSize int
+	// DeadLetterPath is the file deliveries that were given up on are appended to, when it's empty they're only logged
+	DeadLetterPath string
+}
+
+// Dispatcher posts the events of live matches to subscribers. Deliveries that fail are retried with backoff until
+// they run out of attempts, then written to the dead letter log
+type Dispatcher struct {
+	resty       *resty.Client
+	targets     []target
+	options     Options
+	queue       *queue
+	deadLetters *deadLetterLog
+	cancel      context.CancelFunc
+	wg          *sync.WaitGroup
+}
+
+// delivery is an event on its way to a subscriber
+type delivery struct {
+	id        string
+	target    target
+	eventType sports.EventType
+	payload   []byte
+	attempts  int
+	dueAt     time.Time
+}
+
+func NewDispatcher(resty *resty.Client, subscribers []Subscriber, options Options) (*Dispatcher, error) {
+	switch {
+	case options.MaxAttempts <= 0:
+		return nil, fmt.Errorf("webhook max attempts must be positive")
+	case options.RetryBaseDelay <= 0 || options.RetryMaxDelay < options.RetryBaseDelay:
+		return nil, fmt.Errorf("webhook retry delays must be positive, the max at least the base")
+	case options.Workers <= 0:
+		return nil, fmt.Errorf("webhook workers must be positive")
+	case options.QueueSize <= 0:
+		return nil, fmt.Errorf("webhook queue size must be positive")
+	}
+
+	targets := make([]target, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		t, err := newTarget(subscriber)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	// Nothing can be given up on without subscribers, so there's no need for the log's file
+	deadLetterPath := options.DeadLetterPath
+	if len(targets) == 0 {
+		deadLetterPath = ""
+	}
+	deadLetters, err := newDeadLetterLog(deadLetterPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Dispatcher{
+		resty:       resty,
+		targets:     targets,
+		options:     options,
+		queue:       newQueue(options.QueueSize),
+		deadLetters: deadLetters,
+		wg:          &sync.WaitGroup{},
+	}, nil
+}
+
+// Start posts the events published to broker until the dispatcher is closed. It's subscribed by the time it returns,
+// so no events published after are missed. Without subscribers it doesn't subscribe at all
+func (d *Dispatcher) Start(broker *sports.Broker) {
+	if len(d.targets) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	ready := make(chan *delivery)
+	d.wg.Add(2 + d.options.Workers)
+	go d.receive(ctx, broker, broker.Subscribe(nil, d.options.QueueSize))
+	go d.schedule(ctx, ready)
+	for i := 0; i < d.options.Workers; i++ {
+		go d.work(ctx, ready)
+	}
+}
+
+// Close stops making deliveries and gives up on those that are left, as nothing outlives the process to retry them
+func (d *Dispatcher) Close() error {
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
+	}
+
+	for _, delivery := range d.queue.drain() {
+		d.deadLetters.write(delivery, errShuttingDown)
+	}
+
+	return d.deadLetters.close()
+}
+
+// receive queues a delivery of every event for each subscriber that accepts it. It only ever queues, so it keeps up
+// with the broker, but bursts bigger than the queue still drop it and it has to resubscribe
+func (d *Dispatcher) receive(ctx context.Context, broker *sports.Broker, subscription *sports.Subscription) {
+	defer d.wg.Done()
+
+	for d.receiveSubscription(ctx, subscription) {
+		zap.S().Warnw("Resubscribing webhooks to events, some were missed", zap.Error(subscription.Err()))
+		subscription = broker.Subscribe(nil, d.options.QueueSize)
+	}
+}
+
+// receiveSubscription queues deliveries until the subscription ends, ok is true when it's worth subscribing again
+func (d *Dispatcher) receiveSubscription(ctx context.Context, subscription *sports.Subscription) bool {
+	defer subscription.Close()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return errors.Is(subscription.Err(), sports.ErrSubscriberTooSlow)
+			}
+			d.enqueue(event)
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(event sports.Event) {
+	var payload []byte
+	for _, t := range d.targets {
+		if !t.accepts(event) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(api.NewEvent(event)); err != nil {
+				zap.S().Errorw("Unable to encode webhook payload", zap.Error(err))
+				return
+			}
+		}
+
+		delivery := &delivery{
+			id:        newDeliveryID(),
+			target:    t,
+			eventType: event.Type,
+			payload:   payload,
+			dueAt:     time.Now(),
+		}
+		if !d.queue.push(delivery) {
+			d.deadLetters.write(delivery, errQueueFull)
+		}
+	}
+}
+
+// schedule hands deliveries to the workers as they become due
+func (d *Dispatcher) schedule(ctx context.Context, ready chan<- *delivery) {
+	defer d.wg.Done()
+
+	for {
+		delivery, ok := d.queue.pop(ctx)
+		if !ok {
+			return
+		}
+
+		select {
+		case ready <- delivery:
+		case <-ctx.Done():
+			// Put it back to be given up on along with the rest of the queue
+			if !d.queue.push(delivery) {
+				d.deadLetters.write(delivery, errShuttingDown)
+			}
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context, ready <-chan *delivery) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case delivery := <-ready:
+			d.attempt(ctx, delivery)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// attempt makes a delivery, queueing it to be retried after a backoff when it fails and has attempts left
+func (d *Dispatcher) attempt(ctx context.Context, delivery *delivery) {
+	delivery.attempts++
+	retry, err := d.post(ctx, delivery)
+	if err == nil {
+		zap.S().Debugw(
+			"Delivered webhook",
+			zap.String("id", delivery.id),
+			zap.String("subscriber", delivery.target.Name),
+			zap.Int("attempts", delivery.attempts),
+		)
+		return
+	}
+
+	if !retry || delivery.attempts >= d.options.MaxAttempts {
+		d.deadLetters.write(delivery, err)
+		return
+	}
+
+	delay := d.getRetryDelay(delivery.attempts)
+	zap.S().Warnw(
+		"Retrying webhook delivery",
+		zap.String("id", delivery.id),
+		zap.String("subscriber", delivery.target.Name),
+		zap.Int("attempt", delivery.attempts),
+		zap.Duration("delay", delay),
+		zap.Error(err),
+	)
+	delivery.dueAt = time.Now().Add(delay)
+	if !d.queue.push(delivery) {
+		d.deadLetters.write(delivery, fmt.Errorf("%w to retry after: %w", errQueueFull, err))
+	}
+}
+
+// post signs and sends a delivery, retry is true when it failed in a way that might not happen again. Subscribers
+// rejecting a delivery with a 4xx other than a timeout or rate limiting aren't going to accept it later
+func (d *Dispatcher) post(ctx context.Context, delivery *delivery) (retry bool, err error) {
+	timestamp := time.Now().Unix()
+	response, err := d.resty.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(EventHeader, string(delivery.eventType)).
+		SetHeader(DeliveryHeader, delivery.id).
+		SetHeader(TimestampHeader, strconv.FormatInt(timestamp, 10)).
+		SetHeader(SignatureHeader, Sign(delivery.target.Secret, timestamp, delivery.payload)).
+		SetBody(delivery.payload).
+		Post(delivery.target.URL)
+	if err != nil {
+		return true, fmt.Errorf("unable to post webhook: %w", err)
+	}
+
+	status := response.StatusCode()
+	if status >= 200 && status < 300 {
+		return false, nil
+	}
+
+	retry = status >= http.StatusInternalServerError ||
+		status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook rejected with status code %d", status)
+}
+
+// getRetryDelay backs off exponentially with equal jitter, like retries of the sports API
+func (d *Dispatcher) getRetryDelay(attempts int) time.Duration {
+	delay := d.options.RetryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > d.options.RetryMaxDelay {
+		delay = d.options.RetryMaxDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(mathrand.Int63n(int64(half)+1))
+}
+
+// Sign is the signature of a payload sent at timestamp, in seconds since the epoch
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Ids only need to be unique enough for receivers to drop duplicates
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(id)
+}
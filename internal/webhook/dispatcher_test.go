@@ -0,0 +1,392 @@
+package webhook
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/welps/go-frames-scores/internal/api"
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+const testSecret = "secret"
+
+// receivedRequest is a delivery as the receiver saw it
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+// testReceiver stands in for a subscriber, responding to each delivery with the next of its statuses and repeating
+// the last one once it runs out
+type testReceiver struct {
+	server   *httptest.Server
+	requests chan receivedRequest
+}
+
+func newTestReceiver(t *testing.T, statuses ...int) *testReceiver {
+	t.Helper()
+
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusOK}
+	}
+	receiver := &testReceiver{requests: make(chan receivedRequest, 64)}
+	mutex := &sync.Mutex{}
+	received := 0
+	receiver.server = httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("unable to read delivery: %v", err)
+				}
+
+				mutex.Lock()
+				status := statuses[min(received, len(statuses)-1)]
+				received++
+				mutex.Unlock()
+
+				receiver.requests <- receivedRequest{header: r.Header.Clone(), body: body, at: time.Now()}
+				w.WriteHeader(status)
+			},
+		),
+	)
+	t.Cleanup(receiver.server.Close)
+
+	return receiver
+}
+
+// wait returns the next count deliveries
+func (r *testReceiver) wait(t *testing.T, count int) []receivedRequest {
+	t.Helper()
+
+	requests := make([]receivedRequest, 0, count)
+	for len(requests) < count {
+		select {
+		case request := <-r.requests:
+			requests = append(requests, request)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d deliveries, want %d", len(requests), count)
+		}
+	}
+
+	return requests
+}
+
+// expectNoMore checks nothing else is delivered for a while, which also gives the dispatcher time to finish with
+// the responses it's been sent
+func (r *testReceiver) expectNoMore(t *testing.T) {
+	t.Helper()
+
+	select {
+	case request := <-r.requests:
+		t.Errorf("received another delivery: %s", request.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func getTestOptions(t *testing.T) Options {
+	return Options{
+		MaxAttempts:    3,
+		RetryBaseDelay: 20 * time.Millisecond,
+		RetryMaxDelay:  80 * time.Millisecond,
+		Workers:        2,
+		QueueSize:      16,
+		DeadLetterPath: filepath.Join(t.TempDir(), "dead-letters.jsonl"),
+	}
+}
+
+// startTestDispatcher starts delivering the events published to the broker it returns
+func startTestDispatcher(t *testing.T, subscribers []Subscriber, options Options) (*Dispatcher, *sports.Broker) {
+	t.Helper()
+
+	dispatcher, err := NewDispatcher(resty.New(), subscribers, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := sports.NewBroker()
+	dispatcher.Start(broker)
+	t.Cleanup(
+		func() {
+			_ = dispatcher.Close()
+			broker.Close()
+		},
+	)
+
+	return dispatcher, broker
+}
+
+func readDeadLetters(t *testing.T, path string) []deadLetter {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var deadLetters []deadLetter
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var d deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatalf("unable to parse dead letter %q: %v", scanner.Text(), err)
+		}
+		deadLetters = append(deadLetters, d)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return deadLetters
+}
+
+func newTestEvent(id int, gameType sports.GameType, home sports.Team, away sports.Team) sports.Event {
+	return sports.Event{
+		Type:  sports.EventScoreChanged,
+		Match: sports.Match{ID: id, GameType: gameType, Home: home, Away: away},
+		At:    time.Now(),
+	}
+}
+
+func getDeliveredMatchID(t *testing.T, request receivedRequest) int {
+	t.Helper()
+
+	var event api.Event
+	if err := json.Unmarshal(request.body, &event); err != nil {
+		t.Fatalf("unable to parse delivery %q: %v", request.body, err)
+	}
+
+	return event.Match.ID
+}
+
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	receiver := newTestReceiver(t)
+	_, broker := startTestDispatcher(
+		t,
+		[]Subscriber{{URL: receiver.server.URL, Secret: testSecret}},
+		getTestOptions(t),
+	)
+
+	broker.Publish([]sports.Event{newTestEvent(1, sports.Basketball, sports.Team{}, sports.Team{})})
+	request := receiver.wait(t, 1)[0]
+
+	if got := request.header.Get(EventHeader); got != string(sports.EventScoreChanged) {
+		t.Errorf("%s = %q, want %q", EventHeader, got, sports.EventScoreChanged)
+	}
+	if request.header.Get(DeliveryHeader) == "" {
+		t.Errorf("%s is missing", DeliveryHeader)
+	}
+	if got := request.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	timestamp, err := strconv.ParseInt(request.header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s isn't a unix timestamp: %v", TimestampHeader, err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age < -time.Second || age > time.Minute {
+		t.Errorf("%s is %s old", TimestampHeader, age)
+	}
+
+	// Verified the way a receiver would, without Sign
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(request.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := request.header.Get(SignatureHeader); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+
+	if id := getDeliveredMatchID(t, request); id != 1 {
+		t.Errorf("delivered match %d, want 1", id)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		wantAttempts   int
+		wantDeadLetter bool
+	}{
+		{name: "accepted", statuses: []int{http.StatusOK}, wantAttempts: 1},
+		{
+			name:         "server errors retried",
+			statuses:     []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{name: "rate limit retried", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, wantAttempts: 2},
+		{
+			name:           "server errors until out of attempts",
+			statuses:       []int{http.StatusBadGateway},
+			wantAttempts:   3,
+			wantDeadLetter: true,
+		},
+		{name: "rejected", statuses: []int{http.StatusBadRequest}, wantAttempts: 1, wantDeadLetter: true},
+		{name: "gone", statuses: []int{http.StatusGone}, wantAttempts: 1, wantDeadLetter: true},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				receiver := newTestReceiver(t, test.statuses...)
+				options := getTestOptions(t)
+				dispatcher, broker := startTestDispatcher(
+					t,
+					[]Subscriber{{Name: "receiver", URL: receiver.server.URL, Secret: testSecret}},
+					options,
+				)
+
+				broker.Publish([]sports.Event{newTestEvent(1, sports.Basketball, sports.Team{}, sports.Team{})})
+				requests := receiver.wait(t, test.wantAttempts)
+				receiver.expectNoMore(t)
+				if err := dispatcher.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				for i := 1; i < len(requests); i++ {
+					if requests[i].header.Get(DeliveryHeader) != requests[0].header.Get(DeliveryHeader) {
+						t.Errorf("attempt %d has a different delivery id", i+1)
+					}
+					// Backoff doubles from the base delay with up to half of it taken off as jitter
+					delay := min(options.RetryBaseDelay<<(i-1), options.RetryMaxDelay)
+					if gap := requests[i].at.Sub(requests[i-1].at); gap < delay/2 {
+						t.Errorf("attempt %d came %s after the last, want at least %s", i+1, gap, delay/2)
+					}
+				}
+
+				deadLetters := readDeadLetters(t, options.DeadLetterPath)
+				if !test.wantDeadLetter {
+					if len(deadLetters) > 0 {
+						t.Errorf("gave up on %d deliveries, want none", len(deadLetters))
+					}
+					return
+				}
+				if len(deadLetters) != 1 {
+					t.Fatalf("gave up on %d deliveries, want 1", len(deadLetters))
+				}
+				d := deadLetters[0]
+				if d.ID != requests[0].header.Get(DeliveryHeader) || d.Subscriber != "receiver" ||
+					d.Attempts != test.wantAttempts || d.Event != string(sports.EventScoreChanged) {
+					t.Errorf("dead letter = %+v", d)
+				}
+				if string(d.Payload) != string(requests[0].body) {
+					t.Errorf("dead letter payload = %s, want %s", d.Payload, requests[0].body)
+				}
+			},
+		)
+	}
+}
+
+func TestDispatcherFilters(t *testing.T) {
+	lakers := sports.Team{Name: "Los Angeles Lakers", ShortName: "Lakers", Code: "LAL"}
+	celtics := sports.Team{Name: "Boston Celtics", ShortName: "Celtics", Code: "BOS"}
+	nadal := sports.Team{Name: "Rafael Nadal", Code: "NAD"}
+	federer := sports.Team{Name: "Roger Federer", Code: "FED"}
+	united := sports.Team{Name: "Manchester United", ShortName: "Man Utd", Code: "MUN"}
+	city := sports.Team{Name: "Manchester City", ShortName: "Man City", Code: "MCI"}
+	events := []sports.Event{
+		newTestEvent(1, sports.Basketball, lakers, celtics),
+		newTestEvent(2, sports.Tennis, nadal, federer),
+		newTestEvent(3, sports.Soccer, united, city),
+	}
+
+	tests := []struct {
+		name    string
+		sports  []string
+		teams   []string
+		wantIDs []int
+	}{
+		{name: "no filters", wantIDs: []int{1, 2, 3}},
+		{name: "sport", sports: []string{"tennis"}, wantIDs: []int{2}},
+		{name: "sports", sports: []string{"tennis", "soccer"}, wantIDs: []int{2, 3}},
+		{name: "team code ignoring case", teams: []string{"nad"}, wantIDs: []int{2}},
+		{name: "team name", teams: []string{"Boston Celtics"}, wantIDs: []int{1}},
+		{name: "team short name", teams: []string{" Man City "}, wantIDs: []int{3}},
+		{name: "sport and team", sports: []string{"soccer"}, teams: []string{"MUN", "LAL"}, wantIDs: []int{3}},
+		{name: "sport and team of another sport", sports: []string{"basketball"}, teams: []string{"NAD"}},
+	}
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				receiver := newTestReceiver(t)
+				options := getTestOptions(t)
+				dispatcher, broker := startTestDispatcher(
+					t,
+					[]Subscriber{
+						{URL: receiver.server.URL, Secret: testSecret, Sports: test.sports, Teams: test.teams},
+					},
+					options,
+				)
+
+				broker.Publish(events)
+				requests := receiver.wait(t, len(test.wantIDs))
+				receiver.expectNoMore(t)
+				// Anything still queued is given up on, so the dead letter log has whatever wasn't delivered
+				if err := dispatcher.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				ids := make([]int, 0, len(requests))
+				for _, request := range requests {
+					ids = append(ids, getDeliveredMatchID(t, request))
+				}
+				slices.Sort(ids)
+				if !slices.Equal(ids, test.wantIDs) {
+					t.Errorf("delivered matches %v, want %v", ids, test.wantIDs)
+				}
+				if deadLetters := readDeadLetters(t, options.DeadLetterPath); len(deadLetters) > 0 {
+					t.Errorf("gave up on %d deliveries, want none", len(deadLetters))
+				}
+			},
+		)
+	}
+}
+
+func TestDispatcherCloseGivesUpOnQueued(t *testing.T) {
+	receiver := newTestReceiver(t, http.StatusServiceUnavailable)
+	options := getTestOptions(t)
+	// Retries wait far longer than the test, so they're still queued when the dispatcher closes
+	options.RetryBaseDelay, options.RetryMaxDelay = time.Hour, time.Hour
+	dispatcher, broker := startTestDispatcher(
+		t,
+		[]Subscriber{{Name: "receiver", URL: receiver.server.URL, Secret: testSecret}},
+		options,
+	)
+
+	broker.Publish(
+		[]sports.Event{
+			newTestEvent(1, sports.Basketball, sports.Team{}, sports.Team{}),
+			newTestEvent(2, sports.Basketball, sports.Team{}, sports.Team{}),
+		},
+	)
+	receiver.wait(t, 2)
+	receiver.expectNoMore(t)
+	if err := dispatcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetters := readDeadLetters(t, options.DeadLetterPath)
+	if len(deadLetters) != 2 {
+		t.Fatalf("gave up on %d deliveries, want 2", len(deadLetters))
+	}
+	for _, d := range deadLetters {
+		if d.Attempts != 1 || d.Error != errShuttingDown.Error() || d.Subscriber != "receiver" {
+			t.Errorf("dead letter = %+v", d)
+		}
+	}
+}
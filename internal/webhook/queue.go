@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// queue holds deliveries until they're due, the earliest first. Retries wait in it for their backoff to pass
+type queue struct {
+	deliveries deliveryHeap
+	maxSize    int
+	mutex      *sync.Mutex
+	// wake is signalled whenever a delivery is pushed, so a pop waiting on a later one looks again
+	wake chan struct{}
+}
+
+func newQueue(maxSize int) *queue {
+	return &queue{
+		maxSize: maxSize,
+		mutex:   &sync.Mutex{},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// push adds a delivery, ok is false when the queue is already full
+func (q *queue) push(d *delivery) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.deliveries) >= q.maxSize {
+		return false
+	}
+	heap.Push(&q.deliveries, d)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// pop waits for the earliest delivery to be due and takes it, ok is false once ctx is done. Only one goroutine can
+// pop at a time, as pushes only wake one
+func (q *queue) pop(ctx context.Context) (*delivery, bool) {
+	for {
+		q.mutex.Lock()
+		wait := time.Duration(-1)
+		if len(q.deliveries) > 0 {
+			wait = time.Until(q.deliveries[0].dueAt)
+			if wait <= 0 {
+				d := heap.Pop(&q.deliveries).(*delivery)
+				q.mutex.Unlock()
+				return d, true
+			}
+		}
+		q.mutex.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, false
+		}
+	}
+}
+
+// drain takes every delivery left in the queue
+func (q *queue) drain() []*delivery {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	deliveries := q.deliveries
+	q.deliveries = nil
+
+	return deliveries
+}
+
+type deliveryHeap []*delivery
+
+func (h deliveryHeap) Len() int {
+	return len(h)
+}
+
+func (h deliveryHeap) Less(i, j int) bool {
+	return h[i].dueAt.Before(h[j].dueAt)
+}
+
+func (h deliveryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *deliveryHeap) Push(x any) {
+	*h = append(*h, x.(*delivery))
+}
+
+func (h *deliveryHeap) Pop() any {
+	old := *h
+	d := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+
+	return d
+}
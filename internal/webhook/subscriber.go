@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/welps/go-frames-scores/internal/sports"
+)
+
+// Subscriber is somewhere events are posted to. Sports and Teams filter the events it's sent, an empty filter lets
+// every event through
+type Subscriber struct {
+	// Name identifies the subscriber in logs and the dead letter log, it defaults to the URL's host
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret signs every payload posted to the subscriber
+	Secret string `json:"secret"`
+	// Sports are sport slugs, e.g. "tennis"
+	Sports []string `json:"sports"`
+	// Teams are matched against the name, short name and code of both teams of a match, ignoring case
+	Teams []string `json:"teams"`
+}
+
+// target is a subscriber with its filters looked up
+type target struct {
+	Subscriber
+	gameTypes map[sports.GameType]bool
+	teams     map[string]bool
+}
+
+// LoadSubscribers reads a JSON array of subscribers
+func LoadSubscribers(path string) ([]Subscriber, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read webhook subscribers: %w", err)
+	}
+
+	var subscribers []Subscriber
+	if err := json.Unmarshal(data, &subscribers); err != nil {
+		return nil, fmt.Errorf("unable to parse webhook subscribers %s: %w", path, err)
+	}
+
+	return subscribers, nil
+}
+
+func newTarget(subscriber Subscriber) (target, error) {
+	parsed, err := url.Parse(subscriber.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return target{}, fmt.Errorf("webhook url %q isn't an absolute http url", subscriber.URL)
+	}
+	if subscriber.Name == "" {
+		subscriber.Name = parsed.Host
+	}
+	if subscriber.Secret == "" {
+		return target{}, fmt.Errorf("webhook %s has no secret to sign payloads with", subscriber.Name)
+	}
+
+	t := target{
+		Subscriber: subscriber,
+		gameTypes:  make(map[sports.GameType]bool, len(subscriber.Sports)),
+		teams:      make(map[string]bool, len(subscriber.Teams)),
+	}
+	for _, slug := range subscriber.Sports {
+		sport, ok := sports.GetSportBySlug(slug)
+		if !ok {
+			return target{}, fmt.Errorf("webhook %s is for an unknown sport %q", subscriber.Name, slug)
+		}
+		t.gameTypes[sport.GameType] = true
+	}
+	for _, team := range subscriber.Teams {
+		t.teams[strings.ToLower(strings.TrimSpace(team))] = true
+	}
+
+	return t, nil
+}
+
+// accepts is true when event passes both of the target's filters
+func (t target) accepts(event sports.Event) bool {
+	if len(t.gameTypes) > 0 && !t.gameTypes[event.Match.GameType] {
+		return false
+	}
+	if len(t.teams) == 0 {
+		return true
+	}
+
+	for _, team := range []sports.Team{event.Match.Home, event.Match.Away} {
+		for _, name := range []string{team.Name, team.ShortName, team.Code} {
+			if name != "" && t.teams[strings.ToLower(name)] {
+				return true
+			}
+		}
+	}
+
+	return false
+}